@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/nicholas/glint/pkg/analyzer"
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/tools/go/analysis/unitchecker"
+
+	// Register all rules via init()
+	_ "github.com/nicholas/glint/pkg/rules/bugs"
+	_ "github.com/nicholas/glint/pkg/rules/perf"
+	_ "github.com/nicholas/glint/pkg/rules/security"
+	_ "github.com/nicholas/glint/pkg/rules/style"
+)
+
+// glint-vet runs the glint rules as a go vet tool:
+//
+//	go vet -vettool=$(which glint-vet) ./...
+func main() {
+	unitchecker.Main(analyzer.All(rule.GlobalRegistry())...)
+}
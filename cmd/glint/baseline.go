@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nicholas/glint/pkg/baseline"
+	"github.com/spf13/cobra"
+)
+
+const defaultBaselinePath = ".glint-baseline.json"
+
+func baselineCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "baseline",
+		Short: "Manage baseline files of accepted existing issues",
+	}
+	cmd.AddCommand(baselineCreateCmd())
+	return cmd
+}
+
+func baselineCreateCmd() *cobra.Command {
+	var (
+		opts   lintOptions
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create [packages...]",
+		Short: "Record the current diagnostics so later runs only report new ones",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = []string{"./..."}
+			}
+
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+
+			_, diags, err := lint(cfg, args, nil)
+			if err != nil {
+				return err
+			}
+
+			wd, _ := os.Getwd()
+			bl := baseline.Create(diags, baseline.NewFingerprinter(wd))
+			if err := bl.Write(output); err != nil {
+				return err
+			}
+			_, _ = fmt.Printf("Recorded %d issue(s) in %s.\n", len(diags), output)
+			return nil
+		},
+	}
+
+	opts.register(cmd)
+	cmd.Flags().StringVarP(&output, "output", "o", defaultBaselinePath, "baseline file to write")
+
+	return cmd
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nicholas/glint/pkg/config"
+	"github.com/nicholas/glint/pkg/engine"
+	"github.com/spf13/cobra"
+)
+
+func cacheCmd() *cobra.Command {
+	var opts lintOptions
+
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and manage the result cache",
+	}
+	cmd.PersistentFlags().StringVarP(&opts.configPath, "config", "c", "", "path to config file")
+
+	open := func() (*config.Config, *engine.Cache, error) {
+		cfg, err := opts.loadConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+		cacheCfg := cfg.Cache
+		cacheCfg.Enabled = true
+		cacheCfg.Remote = config.RemoteCacheConfig{}
+		cache, err := engine.NewCache(cacheCfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cfg, cache, nil
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clean",
+		Short: "Remove every cache entry",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, cache, err := open()
+			if err != nil {
+				return err
+			}
+			st, err := cache.Status()
+			if err != nil {
+				return err
+			}
+			if err := cache.Clear(); err != nil {
+				return fmt.Errorf("cleaning cache: %w", err)
+			}
+			_, _ = fmt.Printf("Removed %d entries (%s) from %s.\n", st.Entries, formatBytes(st.Bytes), cache.Dir())
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show cache size and the hit rate of the last run",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, cache, err := open()
+			if err != nil {
+				return err
+			}
+			st, err := cache.Status()
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Printf("Directory: %s\n", st.Dir)
+			if !cfg.Cache.Enabled {
+				_, _ = fmt.Println("Enabled:   no")
+			}
+			_, _ = fmt.Printf("Entries:   %d\n", st.Entries)
+			_, _ = fmt.Printf("Size:      %s\n", formatBytes(st.Bytes))
+
+			maxBytes, _ := cfg.Cache.MaxBytes()
+			limit, age := "unbounded", "unbounded"
+			if maxBytes > 0 {
+				limit = formatBytes(maxBytes)
+			}
+			if cfg.Cache.MaxAge > 0 {
+				age = cfg.Cache.MaxAge.String()
+			}
+			_, _ = fmt.Printf("Limits:    max size %s, max age %s\n", limit, age)
+
+			if rs := st.LastRun; rs != nil {
+				_, _ = fmt.Printf("Last run:  %s, %d hit(s), %d miss(es), %.1f%% hit rate\n",
+					rs.Time.Format("2006-01-02 15:04:05"), rs.Hits, rs.Misses, 100*rs.HitRate())
+			} else {
+				_, _ = fmt.Println("Last run:  none recorded")
+			}
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "path",
+		Short: "Print the cache directory",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Println(cfg.Cache.Dir)
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
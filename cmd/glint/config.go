@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/nicholas/glint/pkg/config"
+	"github.com/nicholas/glint/pkg/engine"
+	"github.com/nicholas/glint/pkg/rule"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and check configuration files",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate [files...]",
+		Short: "Check config files for unknown keys, rules, options and invalid values",
+		Long: "Check config files, and the files they extend, for unknown keys, rules and\n" +
+			"options and for invalid values. Without arguments, every config file that\n" +
+			"applies in the current directory or below it is checked.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wd, _ := os.Getwd()
+			files := args
+			if len(files) == 0 {
+				var err error
+				if files, err = config.Files(wd); err != nil {
+					return err
+				}
+				if len(files) == 0 {
+					_, _ = fmt.Println("No config files found; the defaults apply.")
+					return nil
+				}
+			}
+
+			rel := func(path string) string {
+				if r, err := filepath.Rel(wd, path); err == nil && filepath.IsLocal(r) {
+					return r
+				}
+				return path
+			}
+			failed := 0
+			for _, f := range files {
+				err := config.ValidateFile(f)
+				if err == nil {
+					_, _ = fmt.Printf("%s: ok\n", rel(f))
+					continue
+				}
+				failed++
+				var list config.ErrorList
+				if errors.As(err, &list) {
+					for _, e := range list {
+						e.File = rel(e.File)
+					}
+				}
+				_, _ = fmt.Fprintln(os.Stderr, err)
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d config file(s) invalid", failed, len(files))
+			}
+			return nil
+		},
+	})
+
+	cmd.AddCommand(configPrintCmd())
+
+	return cmd
+}
+
+func configPrintCmd() *cobra.Command {
+	var opts lintOptions
+
+	cmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration, noting where each setting comes from",
+		Long: "Print the configuration in effect in the current directory, after defaults,\n" +
+			"config files and flags are applied. Each setting is annotated with its source,\n" +
+			"and the rules that would run are listed at the end.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			wd, _ := os.Getwd()
+			source := func(path string) string {
+				src := cfg.Sources.Of(path)
+				if filepath.IsAbs(src) {
+					if r, err := filepath.Rel(wd, src); err == nil {
+						src = r
+					}
+				}
+				return src
+			}
+
+			var doc yaml.Node
+			if err := doc.Encode(cfg); err != nil {
+				return fmt.Errorf("encoding config: %w", err)
+			}
+			annotate(&doc, "", source)
+			enc := yaml.NewEncoder(os.Stdout)
+			enc.SetIndent(2)
+			if err := enc.Encode(&doc); err != nil {
+				return fmt.Errorf("encoding config: %w", err)
+			}
+			if err := enc.Close(); err != nil {
+				return err
+			}
+
+			active := engine.SelectRules(cfg, rule.GlobalRegistry())
+			_, _ = fmt.Printf("\n# Active rules (%d):\n", len(active))
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			for _, r := range active {
+				rc := cfg.Rules[r.Name()]
+				sev := r.Severity().String()
+				if rc.Severity != "" {
+					sev = rc.Severity
+				}
+				_, key := engine.Selects(cfg, r)
+				why := key + ", " + source(key)
+				_, _ = fmt.Fprintf(tw, "#   %s\t%s\t%s\t%s\n", r.Name(), r.Category(), sev, why)
+			}
+			return tw.Flush()
+		},
+	}
+
+	opts.register(cmd)
+	return cmd
+}
+
+// annotate sets a comment naming the source of each setting below n, the
+// node of the setting at the dotted key path.
+func annotate(n *yaml.Node, path string, source func(string) string) {
+	if n.Kind == yaml.DocumentNode {
+		for _, c := range n.Content {
+			annotate(c, path, source)
+		}
+		return
+	}
+	for i := 0; i+1 < len(n.Content) && n.Kind == yaml.MappingNode; i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		sub := key.Value
+		if path != "" {
+			sub = path + "." + key.Value
+		}
+		switch {
+		case val.Kind == yaml.MappingNode && len(val.Content) > 0:
+			annotate(val, sub, source)
+		case val.Kind == yaml.ScalarNode:
+			val.LineComment = source(sub)
+		default:
+			key.LineComment = source(sub)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+
+	"github.com/nicholas/glint/pkg/engine"
+	"github.com/nicholas/glint/pkg/lsp"
+	"github.com/nicholas/glint/pkg/rule"
+	"github.com/nicholas/glint/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+func lspCmd() *cobra.Command {
+	var opts lintOptions
+
+	cmd := &cobra.Command{
+		Use:   "lsp",
+		Short: "Run a Language Server Protocol server over stdio",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			eng, err := engine.New(cfg, rule.GlobalRegistry())
+			if err != nil {
+				return err
+			}
+			return lsp.NewServer(eng, version.Version).Serve(os.Stdin, os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.configPath, "config", "c", "", "path to config file")
+	cmd.Flags().BoolVar(&opts.enableAll, "enable-all", false, "enable all rules regardless of config")
+
+	return cmd
+}
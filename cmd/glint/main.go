@@ -4,14 +4,21 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/nicholas/glint/pkg/baseline"
+	"github.com/nicholas/glint/pkg/changeset"
 	"github.com/nicholas/glint/pkg/config"
 	"github.com/nicholas/glint/pkg/engine"
+	"github.com/nicholas/glint/pkg/fix"
 	"github.com/nicholas/glint/pkg/report"
 	"github.com/nicholas/glint/pkg/rule"
+	"github.com/nicholas/glint/pkg/version"
 	"github.com/spf13/cobra"
 
 	// Register all rules via init()
@@ -19,33 +26,235 @@ import (
 	_ "github.com/nicholas/glint/pkg/rules/perf"
 	_ "github.com/nicholas/glint/pkg/rules/security"
 	_ "github.com/nicholas/glint/pkg/rules/style"
+	_ "github.com/nicholas/glint/pkg/rules/vet"
 )
 
-var version = "0.1.0"
+// Exit codes: exitIssues when the issues found fail the run, and
+// exitFailure when glint could not lint the code, because of a bad config,
+// packages that fail to load or type-check, or an internal error.
+const (
+	exitIssues  = 1
+	exitFailure = 2
+)
 
 func main() {
 	root := &cobra.Command{
-		Use:     "glint",
-		Short:   "A super fast Go linter",
-		Version: version,
+		Use:          "glint",
+		Short:        "A super fast Go linter",
+		Version:      version.Version,
+		SilenceUsage: true,
 	}
 
 	root.AddCommand(runCmd())
 	root.AddCommand(listRulesCmd())
 	root.AddCommand(initConfigCmd())
+	root.AddCommand(baselineCmd())
+	root.AddCommand(lspCmd())
+	root.AddCommand(cacheCmd())
+	root.AddCommand(configCmd())
 
 	if err := root.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitFailure)
+	}
+}
+
+// lintOptions holds the flags shared by commands that run the engine.
+type lintOptions struct {
+	configPath   string
+	format       string
+	outFormat    string
+	enableAll    bool
+	noCache      bool
+	concurrency  int
+	newFromRev   string
+	newFromPatch string
+	tags         []string
+	tests        bool
+	goos         string
+	goarch       string
+	enable       []string
+	disable      []string
+	presets      []string
+	set          []string
+
+	cmd *cobra.Command
+}
+
+func (o *lintOptions) register(cmd *cobra.Command) {
+	o.cmd = cmd
+	cmd.Flags().StringVarP(&o.configPath, "config", "c", "", "path to config file")
+	cmd.Flags().StringVarP(&o.format, "format", "f", "", "output format: text, json, sarif")
+	cmd.Flags().StringVar(&o.outFormat, "out-format", "", "comma-separated outputs, each format or format:path, e.g. text,sarif:glint.sarif")
+	cmd.Flags().BoolVar(&o.enableAll, "enable-all", false, "enable all rules regardless of config")
+	cmd.Flags().BoolVar(&o.noCache, "no-cache", false, "disable result caching")
+	cmd.Flags().IntVarP(&o.concurrency, "concurrency", "j", 0, "number of concurrent workers (0 = NumCPU)")
+	cmd.Flags().StringSliceVar(&o.tags, "tags", nil, "comma-separated build tags")
+	cmd.Flags().BoolVar(&o.tests, "tests", true, "lint _test.go files and test packages")
+	cmd.Flags().StringVar(&o.goos, "goos", "", "target operating system (default: GOOS)")
+	cmd.Flags().StringVar(&o.goarch, "goarch", "", "target architecture (default: GOARCH)")
+	cmd.Flags().StringSliceVar(&o.enable, "enable", nil, "comma-separated rules to enable")
+	cmd.Flags().StringSliceVar(&o.disable, "disable", nil, "comma-separated rules to disable")
+	cmd.Flags().StringSliceVar(&o.presets, "preset", nil, "comma-separated presets to select rules from, instead of enable_all: "+
+		strings.Join(rule.PresetNames(), ", "))
+	cmd.Flags().StringArrayVar(&o.set, "set", nil, "override a config setting, as key=value (repeatable)")
+}
+
+// changes returns the change set selected by --new-from-rev or
+// --new-from-patch, or nil when neither is set.
+func (o *lintOptions) changes() (*changeset.Set, error) {
+	wd, _ := os.Getwd()
+	switch {
+	case o.newFromRev != "" && o.newFromPatch != "":
+		return nil, fmt.Errorf("--new-from-rev and --new-from-patch are mutually exclusive")
+	case o.newFromRev != "":
+		return changeset.FromRevision(wd, o.newFromRev)
+	case o.newFromPatch != "":
+		f, err := os.Open(o.newFromPatch)
+		if err != nil {
+			return nil, fmt.Errorf("reading patch: %w", err)
+		}
+		defer f.Close()
+		return changeset.ParsePatch(f, wd)
 	}
+	return nil, nil
+}
+
+// loadConfig reads the config file and applies flag overrides.
+func (o *lintOptions) loadConfig() (*config.Config, error) {
+	var cfg *config.Config
+	var err error
+	if o.configPath != "" {
+		cfg, err = config.LoadFile(o.configPath)
+	} else {
+		wd, _ := os.Getwd()
+		cfg, err = config.Load(wd)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	warnings, err := cfg.ApplyEnv(os.Environ())
+	for _, w := range warnings {
+		_, _ = fmt.Fprintf(os.Stderr, "glint: warning: %s\n", w)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range o.set {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("--set %s: want key=value", kv)
+		}
+		if err := cfg.Set(key, value, "flag --set"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Presets on the command line replace those of the config, and
+	// enable_all, which would make them moot.
+	if o.presets != nil {
+		if err := cfg.Set("presets", strings.Join(o.presets, ","), "flag --preset"); err != nil {
+			return nil, err
+		}
+		if err := cfg.Set("enable_all", "false", "flag --preset"); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range o.enable {
+		if err := cfg.Set("rules."+name+".enabled", "true", "flag --enable"); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range o.disable {
+		if err := cfg.Set("rules."+name+".enabled", "false", "flag --disable"); err != nil {
+			return nil, err
+		}
+	}
+	// Either flag replaces the outputs of the config.
+	if o.format != "" && o.outFormat != "" {
+		return nil, fmt.Errorf("--format and --out-format are mutually exclusive")
+	}
+	if o.format != "" {
+		if err := cfg.Set("output.format", o.format, "flag --format"); err != nil {
+			return nil, err
+		}
+		cfg.Output.Outputs = nil
+		cfg.Sources.Set("output.outputs", "flag --format")
+	}
+	if o.outFormat != "" {
+		outputs, err := config.ParseOutputs(o.outFormat)
+		if err != nil {
+			return nil, fmt.Errorf("--out-format: %w", err)
+		}
+		cfg.Output.Outputs = outputs
+		cfg.Sources.Set("output.outputs", "flag --out-format")
+	}
+	if o.enableAll {
+		cfg.EnableAll = true
+		cfg.Sources.Set("enable_all", "flag --enable-all")
+	}
+	if o.noCache {
+		cfg.Cache.Enabled = false
+		cfg.Sources.Set("cache.enabled", "flag --no-cache")
+	}
+	if o.concurrency > 0 {
+		cfg.Concurrency = o.concurrency
+		cfg.Sources.Set("concurrency", "flag --concurrency")
+	}
+	if o.cmd != nil && o.cmd.Flags().Changed("tests") {
+		cfg.Load.Tests = o.tests
+		cfg.Sources.Set("load.tests", "flag --tests")
+	}
+	// An explicit build on the command line replaces the matrix.
+	if o.tags != nil || o.goos != "" || o.goarch != "" {
+		if cfg.Load.Matrix != nil {
+			cfg.Load.Matrix = nil
+			cfg.Sources.Set("load.matrix", "flag --tags, --goos or --goarch")
+		}
+	}
+	if o.tags != nil {
+		cfg.Load.Tags = o.tags
+		cfg.Sources.Set("load.tags", "flag --tags")
+	}
+	if o.goos != "" {
+		cfg.Load.GOOS = o.goos
+		cfg.Sources.Set("load.goos", "flag --goos")
+	}
+	if o.goarch != "" {
+		cfg.Load.GOARCH = o.goarch
+		cfg.Sources.Set("load.goarch", "flag --goarch")
+	}
+	return cfg, nil
+}
+
+// lint builds an engine from cfg and runs it on the given patterns,
+// restricted to changes if it is non-nil.
+func lint(cfg *config.Config, patterns []string, changes *changeset.Set) (*engine.Engine, []rule.Diagnostic, error) {
+	eng, err := engine.New(cfg, rule.GlobalRegistry())
+	if err != nil {
+		return nil, nil, err
+	}
+	if changes != nil {
+		eng.RestrictTo(changes)
+	}
+
+	diags, err := eng.Run(context.Background(), patterns)
+	if err != nil {
+		return nil, nil, fmt.Errorf("analysis failed: %w", err)
+	}
+	return eng, diags, nil
 }
 
 func runCmd() *cobra.Command {
 	var (
-		configPath  string
-		format      string
-		enableAll   bool
-		noCache     bool
-		concurrency int
+		opts         lintOptions
+		baselinePath string
+		applyFixes   bool
+		showDiff     bool
+		watch        bool
+		interval     time.Duration
+		failOn       string
+		maxIssues    int
 	)
 
 	cmd := &cobra.Command{
@@ -57,67 +266,170 @@ func runCmd() *cobra.Command {
 			}
 			start := time.Now()
 
-			var cfg *config.Config
-			var err error
-			if configPath != "" {
-				cfg, err = config.LoadFile(configPath)
-			} else {
-				wd, _ := os.Getwd()
-				cfg, err = config.Load(wd)
-			}
+			cfg, err := opts.loadConfig()
 			if err != nil {
-				return fmt.Errorf("loading config: %w", err)
-			}
-
-			if format != "" {
-				cfg.Output.Format = format
+				return err
 			}
-			if enableAll {
-				cfg.EnableAll = true
+			if cmd.Flags().Changed("fail-on") {
+				if err := cfg.Set("output.fail_on", failOn, "flag --fail-on"); err != nil {
+					return err
+				}
 			}
-			if noCache {
-				cfg.Cache.Enabled = false
+			if cmd.Flags().Changed("max-issues") {
+				if err := cfg.Set("output.max_issues", strconv.Itoa(maxIssues), "flag --max-issues"); err != nil {
+					return err
+				}
 			}
-			if concurrency > 0 {
-				cfg.Concurrency = concurrency
+
+			if watch {
+				if applyFixes || showDiff || baselinePath != "" || opts.newFromRev != "" || opts.newFromPatch != "" {
+					return fmt.Errorf("--watch cannot be combined with --fix, --diff, --baseline or --new-from-*")
+				}
+				return watchLoop(cfg, args, interval)
 			}
 
-			eng, err := engine.New(cfg, rule.GlobalRegistry())
+			changes, err := opts.changes()
 			if err != nil {
 				return err
 			}
 
-			diags, err := eng.Run(context.Background(), args)
+			eng, diags, err := lint(cfg, args, changes)
 			if err != nil {
-				return fmt.Errorf("analysis failed: %w", err)
+				return err
+			}
+
+			if baselinePath != "" {
+				bl, blErr := baseline.Load(baselinePath)
+				if blErr != nil {
+					return blErr
+				}
+				wd, _ := os.Getwd()
+				total := len(diags)
+				diags = bl.Filter(diags, baseline.NewFingerprinter(wd))
+				_, _ = fmt.Fprintf(os.Stderr, "glint: %d issue(s) matched the baseline\n", total-len(diags))
+			}
+
+			if applyFixes || showDiff {
+				remaining, fixErr := fixDiagnostics(diags, applyFixes, showDiff)
+				if fixErr != nil {
+					return fixErr
+				}
+				// The diff replaces the report, but the issues it would
+				// fix still count towards the exit code.
+				if !applyFixes {
+					if code := exitCode(cfg.Output, diags); code != 0 {
+						os.Exit(code)
+					}
+					return nil
+				}
+				diags = remaining
 			}
 
-			reporter := report.New(cfg.Output.Format, cfg.Output.Color)
-			reportErr := reporter.Report(os.Stdout, diags)
-			if reportErr != nil {
-				return fmt.Errorf("reporting: %w", reportErr)
+			if err := writeReports(cfg.Output, diags); err != nil {
+				return err
 			}
 
 			elapsed := time.Since(start)
 			_, _ = fmt.Fprintf(os.Stderr, "glint: analyzed %d package(s) with %d rule(s) in %s\n",
 				len(args), len(eng.ActiveRules()), elapsed.Round(time.Millisecond))
 
-			if len(diags) > 0 {
-				os.Exit(1)
+			if code := exitCode(cfg.Output, diags); code != 0 {
+				os.Exit(code)
 			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&configPath, "config", "c", "", "path to config file")
-	cmd.Flags().StringVarP(&format, "format", "f", "", "output format: text, json, sarif")
-	cmd.Flags().BoolVar(&enableAll, "enable-all", false, "enable all rules regardless of config")
-	cmd.Flags().BoolVar(&noCache, "no-cache", false, "disable result caching")
-	cmd.Flags().IntVarP(&concurrency, "concurrency", "j", 0, "number of concurrent workers (0 = NumCPU)")
+	opts.register(cmd)
+	cmd.Flags().StringVar(&baselinePath, "baseline", "", "only report issues not recorded in this baseline file")
+	cmd.Flags().BoolVar(&applyFixes, "fix", false, "apply suggested fixes and report the remaining issues")
+	cmd.Flags().BoolVar(&showDiff, "diff", false, "print suggested fixes as a unified diff")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "re-analyze affected packages whenever files change")
+	cmd.Flags().DurationVar(&interval, "watch-interval", 500*time.Millisecond, "how often to poll for changes in watch mode")
+	cmd.Flags().StringVar(&opts.newFromRev, "new-from-rev", "", "only report issues on lines changed since this git revision")
+	cmd.Flags().StringVar(&opts.newFromPatch, "new-from-patch", "", "only report issues on lines added by this unified diff")
+	cmd.Flags().StringVar(&failOn, "fail-on", "info", "lowest severity that fails the run: error, warning, info or none")
+	cmd.Flags().IntVar(&maxIssues, "max-issues", 0, "number of failing issues tolerated before the run fails")
 
 	return cmd
 }
 
+// writeReports writes a report of diags for each output of out: to
+// standard output, or atomically to a file.
+func writeReports(out config.OutputConfig, diags []rule.Diagnostic) error {
+	for _, t := range out.Targets() {
+		if t.Path == "" {
+			if err := report.New(t.Format, out.Color).Report(os.Stdout, diags); err != nil {
+				return fmt.Errorf("reporting: %w", err)
+			}
+			continue
+		}
+		if err := report.WriteFile(report.New(t.Format, false), t.Path, diags); err != nil {
+			return fmt.Errorf("writing %s report: %w", t.Format, err)
+		}
+	}
+	return nil
+}
+
+// exitCode returns the exit code of a run that reported diags: exitFailure
+// if packages failed to load or type-check, whatever out.FailOn is,
+// exitIssues if more than out.MaxIssues of diags are at least as severe as
+// out.FailOn, and 0 otherwise.
+func exitCode(out config.OutputConfig, diags []rule.Diagnostic) int {
+	if slices.ContainsFunc(diags, func(d rule.Diagnostic) bool { return d.Rule == engine.TypecheckRule }) {
+		return exitFailure
+	}
+	if out.FailOn == "none" {
+		return 0
+	}
+	threshold, err := rule.ParseSeverity(out.FailOn)
+	if err != nil {
+		threshold = rule.SeverityInfo
+	}
+	n := 0
+	for _, d := range diags {
+		if d.Severity >= threshold {
+			n++
+		}
+	}
+	if n > out.MaxIssues {
+		return exitIssues
+	}
+	if n > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "glint: %d issue(s) at %s or above, within max_issues (%d)\n", n, out.FailOn, out.MaxIssues)
+	}
+	return 0
+}
+
+// fixDiagnostics plans the suggested fixes for diags, optionally prints
+// them as a diff and writes them, and returns the diagnostics left unfixed.
+func fixDiagnostics(diags []rule.Diagnostic, write, diff bool) ([]rule.Diagnostic, error) {
+	res, err := fix.Plan(diags)
+	if err != nil {
+		return nil, err
+	}
+
+	if diff {
+		for _, c := range res.Changes {
+			_, _ = fmt.Print(c.Diff())
+		}
+	}
+	if write {
+		if err := res.Write(); err != nil {
+			return nil, fmt.Errorf("applying fixes: %w", err)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "glint: fixed issues in %d file(s)\n", len(res.Changes))
+	}
+
+	remaining := make([]rule.Diagnostic, 0, len(diags))
+	for i, d := range diags {
+		if !res.Fixed[i] {
+			remaining = append(remaining, d)
+		}
+	}
+	return remaining, nil
+}
+
 func listRulesCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "rules",
@@ -132,14 +444,18 @@ func listRulesCmd() *cobra.Command {
 			})
 
 			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintf(tw, "RULE\tCATEGORY\tSEVERITY\tTYPES\tDESCRIPTION\n")
+			_, _ = fmt.Fprintf(tw, "RULE\tCATEGORY\tSEVERITY\tTYPES\tTAGS\tDESCRIPTION\n")
 			for _, r := range rules {
 				needsTypes := "no"
 				if r.NeedsTypeInfo() {
 					needsTypes = "yes"
 				}
-				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-					r.Name(), r.Category(), r.Severity(), needsTypes, r.Description())
+				tags := "-"
+				if t, ok := r.(rule.Tagged); ok && len(t.Tags()) > 0 {
+					tags = strings.Join(t.Tags(), ",")
+				}
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					r.Name(), r.Category(), r.Severity(), needsTypes, tags, r.Description())
 			}
 			return tw.Flush()
 		},
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/nicholas/glint/pkg/config"
+	"github.com/nicholas/glint/pkg/engine"
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+func TestExitCode(t *testing.T) {
+	var (
+		info     = rule.Diagnostic{Rule: "import-order", Severity: rule.SeverityInfo}
+		warning  = rule.Diagnostic{Rule: "shadow-var", Severity: rule.SeverityWarning}
+		errDiag  = rule.Diagnostic{Rule: "unchecked-error", Severity: rule.SeverityError}
+		typeErrs = rule.Diagnostic{Rule: engine.TypecheckRule, Severity: rule.SeverityError}
+	)
+	tests := []struct {
+		failOn    string
+		maxIssues int
+		diags     []rule.Diagnostic
+		want      int
+	}{
+		{"info", 0, nil, 0},
+		{"info", 0, []rule.Diagnostic{info}, exitIssues},
+		{"warning", 0, []rule.Diagnostic{info}, 0},
+		{"warning", 0, []rule.Diagnostic{info, warning}, exitIssues},
+		{"error", 0, []rule.Diagnostic{info, warning}, 0},
+		{"error", 0, []rule.Diagnostic{errDiag}, exitIssues},
+		{"none", 0, []rule.Diagnostic{info, warning, errDiag}, 0},
+		{"info", 2, []rule.Diagnostic{info, warning}, 0},
+		{"info", 2, []rule.Diagnostic{info, warning, errDiag}, exitIssues},
+		{"warning", 1, []rule.Diagnostic{info, info, warning}, 0},
+
+		// Packages that fail to type-check fail the run whatever fail_on
+		// and max_issues say.
+		{"info", 0, []rule.Diagnostic{typeErrs}, exitFailure},
+		{"error", 0, []rule.Diagnostic{warning, typeErrs}, exitFailure},
+		{"none", 0, []rule.Diagnostic{typeErrs}, exitFailure},
+		{"none", 0, []rule.Diagnostic{info, typeErrs}, exitFailure},
+		{"info", 10, []rule.Diagnostic{typeErrs}, exitFailure},
+	}
+	for _, tt := range tests {
+		out := config.OutputConfig{FailOn: tt.failOn, MaxIssues: tt.maxIssues}
+		if got := exitCode(out, tt.diags); got != tt.want {
+			t.Errorf("exitCode(fail_on %s, max_issues %d, %d diagnostics) = %d, want %d",
+				tt.failOn, tt.maxIssues, len(tt.diags), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/nicholas/glint/pkg/config"
+	"github.com/nicholas/glint/pkg/engine"
+	"github.com/nicholas/glint/pkg/report"
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+// watchLoop runs the engine in watch mode until interrupted. The first
+// cycle prints the full report; later ones print only what changed when
+// the output format is text, and the full report otherwise. File outputs
+// are rewritten with the full report on every cycle.
+func watchLoop(cfg *config.Config, patterns []string, interval time.Duration) error {
+	eng, err := engine.New(cfg, rule.GlobalRegistry())
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var (
+		reporter report.Reporter
+		files    []config.OutputTarget
+	)
+	for _, t := range cfg.Output.Targets() {
+		if t.Path == "" {
+			reporter = report.New(t.Format, cfg.Output.Color)
+		} else {
+			files = append(files, t)
+		}
+	}
+	text, isText := reporter.(*report.TextReporter)
+	first := true
+
+	return eng.Watch(ctx, patterns, interval, func(c engine.WatchCycle) {
+		stamp := time.Now().Format("15:04:05")
+		switch {
+		case reporter == nil:
+		case first || !isText:
+			_ = reporter.Report(os.Stdout, c.Diagnostics)
+		default:
+			_ = text.ReportChanges(os.Stdout, c.Added, c.Resolved)
+		}
+		first = false
+		for _, t := range files {
+			if err := report.WriteFile(report.New(t.Format, false), t.Path, c.Diagnostics); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "glint: writing %s report: %v\n", t.Format, err)
+			}
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "glint: [%s] %d issue(s) (+%d, -%d) after re-analyzing %d package(s); watching for changes\n",
+			stamp, len(c.Diagnostics), len(c.Added), len(c.Resolved), c.Packages)
+	})
+}
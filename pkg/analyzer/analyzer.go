@@ -0,0 +1,227 @@
+package analyzer
+
+import (
+	"flag"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nicholas/glint/pkg/engine"
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/tools/go/analysis"
+)
+
+// New wraps r as a go/analysis analyzer, so it can run under go vet,
+// gopls or any other analysis driver. Dashes in the rule name become
+// underscores, since analyzer names must be identifiers; the rule's
+// options become analyzer flags, e.g. -line_length.max=100 under go vet.
+func New(r rule.Rule) *analysis.Analyzer {
+	opts, err := rule.ResolveOptions(r, nil)
+	if err != nil {
+		opts = rule.Options{}
+	}
+
+	a := &analysis.Analyzer{
+		Name:             strings.ReplaceAll(r.Name(), "-", "_"),
+		Doc:              r.Description(),
+		RunDespiteErrors: !r.NeedsTypeInfo(),
+	}
+	for _, spec := range rule.Schema(r) {
+		a.Flags.Var(&optionFlag{opts: opts, spec: spec}, spec.Name, spec.Description)
+	}
+
+	walker := engine.NewWalker([]rule.Rule{r})
+	a.Run = func(pass *analysis.Pass) (any, error) {
+		run(pass, r, walker, opts)
+		return nil, nil
+	}
+	return a
+}
+
+// All wraps every rule in reg, sorted by name. Program rules, which need
+// every package at once, and rules that are themselves backed by an
+// analyzer are skipped.
+func All(reg *rule.Registry) []*analysis.Analyzer {
+	rules := reg.All()
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name() < rules[j].Name() })
+
+	out := make([]*analysis.Analyzer, 0, len(rules))
+	for _, r := range rules {
+		switch r.(type) {
+		case rule.AnalyzerRule, rule.ProgramRule:
+			continue
+		}
+		out = append(out, New(r))
+	}
+	return out
+}
+
+func run(pass *analysis.Pass, r rule.Rule, walker *engine.Walker, opts rule.Options) {
+	files := make(map[string]*token.File, len(pass.Files))
+	for _, f := range pass.Files {
+		if tf := pass.Fset.File(f.Pos()); tf != nil {
+			files[tf.Name()] = tf
+		}
+	}
+
+	if pr, ok := r.(rule.PackageRule); ok {
+		runPackage(pass, pr, files, opts)
+	}
+
+	for _, f := range pass.Files {
+		tf := pass.Fset.File(f.Pos())
+		if tf == nil {
+			continue
+		}
+		src, err := pass.ReadFile(tf.Name())
+		if err != nil {
+			src = nil
+		}
+
+		ctx := &rule.Context{
+			File:        f,
+			FileSet:     pass.Fset,
+			TypeInfo:    pass.TypesInfo,
+			Pkg:         pass.Pkg,
+			FileHash:    engine.HashFile(src),
+			FilePath:    tf.Name(),
+			Src:         src,
+			RuleOptions: map[string]rule.Options{r.Name(): opts},
+		}
+		for _, d := range walker.Walk(ctx) {
+			if ad, ok := toAnalysis(files, d); ok {
+				pass.Report(ad)
+			}
+		}
+	}
+}
+
+func runPackage(pass *analysis.Pass, r rule.PackageRule, files map[string]*token.File, opts rule.Options) {
+	pctx := &rule.PackageContext{
+		Path:        pass.Pkg.Path(),
+		FileSet:     pass.Fset,
+		TypeInfo:    pass.TypesInfo,
+		Pkg:         pass.Pkg,
+		RuleOptions: map[string]rule.Options{r.Name(): opts},
+	}
+	for _, f := range pass.Files {
+		if tf := pass.Fset.File(f.Pos()); tf != nil {
+			pctx.Files = append(pctx.Files, f)
+			pctx.FilePaths = append(pctx.FilePaths, tf.Name())
+		}
+	}
+	for _, imp := range pass.Pkg.Imports() {
+		pctx.Imports = append(pctx.Imports, imp.Path())
+	}
+	for _, d := range r.CheckPackage(pctx) {
+		if ad, ok := toAnalysis(files, d); ok {
+			pass.Report(ad)
+		}
+	}
+}
+
+// toAnalysis converts d, whose positions carry byte offsets, back to
+// token.Pos values in the pass's file set.
+func toAnalysis(files map[string]*token.File, d rule.Diagnostic) (analysis.Diagnostic, bool) {
+	pos, ok := tokenPos(files, d.Pos)
+	if !ok {
+		return analysis.Diagnostic{}, false
+	}
+	end, _ := tokenPos(files, d.End)
+
+	ad := analysis.Diagnostic{
+		Pos:      pos,
+		End:      end,
+		Category: d.Rule,
+		Message:  d.Message,
+	}
+	for _, fix := range d.SuggestedFixes {
+		af := analysis.SuggestedFix{Message: fix.Message}
+		for _, e := range fix.Edits {
+			tf := files[e.Filename]
+			if tf == nil || e.End > tf.Size() {
+				af.TextEdits = nil
+				break
+			}
+			af.TextEdits = append(af.TextEdits, analysis.TextEdit{
+				Pos:     tf.Pos(e.Start),
+				End:     tf.Pos(e.End),
+				NewText: []byte(e.NewText),
+			})
+		}
+		if len(af.TextEdits) > 0 {
+			ad.SuggestedFixes = append(ad.SuggestedFixes, af)
+		}
+	}
+	return ad, true
+}
+
+func tokenPos(files map[string]*token.File, p token.Position) (token.Pos, bool) {
+	tf := files[p.Filename]
+	if tf == nil || p.Offset < 0 || p.Offset > tf.Size() {
+		return token.NoPos, false
+	}
+	return tf.Pos(p.Offset), true
+}
+
+// optionFlag sets one rule option from the command line.
+type optionFlag struct {
+	opts rule.Options
+	spec rule.OptionSpec
+}
+
+var _ flag.Value = (*optionFlag)(nil)
+
+func (f *optionFlag) String() string {
+	if f == nil || f.opts == nil {
+		return ""
+	}
+	switch v := f.opts[f.spec.Name].(type) {
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return toString(v)
+	}
+}
+
+func (f *optionFlag) Set(s string) error {
+	var raw any = s
+	switch f.spec.Type {
+	case rule.OptionInt:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		raw = n
+	case rule.OptionBool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		raw = b
+	case rule.OptionStringList:
+		raw = strings.Split(s, ",")
+	}
+	v, err := rule.CoerceOption(f.spec.Type, raw)
+	if err != nil {
+		return err
+	}
+	f.opts[f.spec.Name] = v
+	return nil
+}
+
+// IsBoolFlag lets boolean options be given as -rule.opt without a value.
+func (f *optionFlag) IsBoolFlag() bool { return f.spec.Type == rule.OptionBool }
+
+func toString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+const formatVersion = 1
+
+// Baseline is a snapshot of accepted diagnostics. Entries are keyed by
+// fingerprint rather than position, so they keep matching after
+// unrelated edits shift lines around.
+type Baseline struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+type Entry struct {
+	Fingerprint string `json:"fingerprint"`
+	Rule        string `json:"rule"`
+	File        string `json:"file"`
+	Function    string `json:"function,omitempty"`
+	Message     string `json:"message"`
+	// Count is the number of identical findings; a new copy of an
+	// already-baselined line is still reported.
+	Count int `json:"count"`
+}
+
+// Create builds a baseline from diagnostics.
+func Create(diags []rule.Diagnostic, fp *Fingerprinter) *Baseline {
+	byPrint := make(map[string]*Entry)
+	for _, d := range diags {
+		info := fp.Fingerprint(d)
+		if e, ok := byPrint[info.Hash]; ok {
+			e.Count++
+			continue
+		}
+		byPrint[info.Hash] = &Entry{
+			Fingerprint: info.Hash,
+			Rule:        d.Rule,
+			File:        info.File,
+			Function:    info.Function,
+			Message:     d.Message,
+			Count:       1,
+		}
+	}
+
+	b := &Baseline{Version: formatVersion, Entries: make([]Entry, 0, len(byPrint))}
+	for _, e := range byPrint {
+		b.Entries = append(b.Entries, *e)
+	}
+	sort.Slice(b.Entries, func(i, j int) bool {
+		a, c := b.Entries[i], b.Entries[j]
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		if a.Rule != c.Rule {
+			return a.Rule < c.Rule
+		}
+		return a.Fingerprint < c.Fingerprint
+	})
+	return b
+}
+
+// Filter returns the diagnostics not covered by the baseline.
+func (b *Baseline) Filter(diags []rule.Diagnostic, fp *Fingerprinter) []rule.Diagnostic {
+	remaining := make(map[string]int, len(b.Entries))
+	for _, e := range b.Entries {
+		remaining[e.Fingerprint] += e.Count
+	}
+
+	out := make([]rule.Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		hash := fp.Fingerprint(d).Hash
+		if remaining[hash] > 0 {
+			remaining[hash]--
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing baseline %s: %w", path, err)
+	}
+	if b.Version != formatVersion {
+		return nil, fmt.Errorf("baseline %s has unsupported version %d", path, b.Version)
+	}
+	return &b, nil
+}
+
+func (b *Baseline) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling baseline: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
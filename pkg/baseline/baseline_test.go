@@ -0,0 +1,174 @@
+package baseline
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+const before = `package p
+
+import "os"
+
+func f() {
+	os.Remove("a")
+	x := 1
+	_ = x
+}
+
+func (s *Stack[T]) Push(v T) {
+	os.Remove("b")
+}
+`
+
+// after is before with a function and an import added above, unrelated
+// lines edited and a flagged call re-indented.
+const after = `package p
+
+import (
+	"fmt"
+	"os"
+)
+
+func g() {
+	os.Remove("a")
+}
+
+func f() {
+	fmt.Println("unrelated")
+	os.Remove("a")
+	y := 2
+	_ = y
+}
+
+func (s *Stack[T]) Push(v T) {
+	if true {
+		os.Remove("b")
+	}
+}
+`
+
+func writeFile(t *testing.T, root, content string) string {
+	t.Helper()
+	path := filepath.Join(root, "pkg", "p.go")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func diag(ruleName, file string, line int) rule.Diagnostic {
+	return rule.Diagnostic{
+		Rule:    ruleName,
+		Message: "error return value is not checked",
+		Pos:     token.Position{Filename: file, Line: line, Column: 2},
+	}
+}
+
+func TestFingerprintStable(t *testing.T) {
+	// The two versions live in different checkouts.
+	oldRoot, newRoot := t.TempDir(), t.TempDir()
+	oldFile, newFile := writeFile(t, oldRoot, before), writeFile(t, newRoot, after)
+	oldFP, newFP := NewFingerprinter(oldRoot), NewFingerprinter(newRoot)
+
+	tests := []struct {
+		name             string
+		oldLine, newLine int
+		function         string
+	}{
+		{"shifted down", 6, 14, "f"},
+		{"shifted and re-indented", 12, 21, "Stack.Push"},
+	}
+	for _, tt := range tests {
+		old := oldFP.Fingerprint(diag("unchecked-error", oldFile, tt.oldLine))
+		cur := newFP.Fingerprint(diag("unchecked-error", newFile, tt.newLine))
+		if old != cur {
+			t.Errorf("%s: fingerprint %+v, want %+v", tt.name, cur, old)
+		}
+		if old.File != "pkg/p.go" || old.Function != tt.function {
+			t.Errorf("%s: file %q, function %q; want pkg/p.go, %s", tt.name, old.File, old.Function, tt.function)
+		}
+	}
+}
+
+func TestFingerprintChanges(t *testing.T) {
+	oldRoot, newRoot := t.TempDir(), t.TempDir()
+	oldFile, newFile := writeFile(t, oldRoot, before), writeFile(t, newRoot, after)
+	oldFP, newFP := NewFingerprinter(oldRoot), NewFingerprinter(newRoot)
+
+	tests := []struct {
+		name     string
+		old, cur rule.Diagnostic
+		fp       *Fingerprinter
+	}{
+		{"other rule", diag("unchecked-error", oldFile, 6), diag("shadow-var", oldFile, 6), oldFP},
+		{"other line", diag("unchecked-error", oldFile, 6), diag("unchecked-error", oldFile, 7), oldFP},
+		{"edited line", diag("unchecked-error", oldFile, 7), diag("unchecked-error", newFile, 15), newFP},
+		{"same text in another function", diag("unchecked-error", oldFile, 6), diag("unchecked-error", newFile, 9), newFP},
+	}
+	for _, tt := range tests {
+		if oldFP.Fingerprint(tt.old).Hash == tt.fp.Fingerprint(tt.cur).Hash {
+			t.Errorf("%s: fingerprint unchanged", tt.name)
+		}
+	}
+}
+
+func TestBaselineFilter(t *testing.T) {
+	oldRoot, newRoot := t.TempDir(), t.TempDir()
+	oldFile, newFile := writeFile(t, oldRoot, before), writeFile(t, newRoot, after)
+
+	b := Create([]rule.Diagnostic{
+		diag("unchecked-error", oldFile, 6),
+		diag("unchecked-error", oldFile, 12),
+	}, NewFingerprinter(oldRoot))
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := b.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fresh := diag("unchecked-error", newFile, 9)
+	got := b.Filter([]rule.Diagnostic{
+		diag("unchecked-error", newFile, 14),
+		diag("unchecked-error", newFile, 21),
+		fresh,
+	}, NewFingerprinter(newRoot))
+	if len(got) != 1 || got[0].Pos != fresh.Pos {
+		t.Errorf("Filter kept %+v, want only the new finding", got)
+	}
+}
+
+func TestBaselineCounts(t *testing.T) {
+	root := t.TempDir()
+	file := writeFile(t, root, "package p\n\nfunc f() {\n\tg()\n\tg()\n\tg()\n}\n")
+	fp := NewFingerprinter(root)
+
+	// Two identical findings are baselined; a third copy is new.
+	b := Create([]rule.Diagnostic{diag("r", file, 4), diag("r", file, 5)}, fp)
+	if len(b.Entries) != 1 || b.Entries[0].Count != 2 {
+		t.Fatalf("entries %+v, want one with count 2", b.Entries)
+	}
+	got := b.Filter([]rule.Diagnostic{diag("r", file, 4), diag("r", file, 5), diag("r", file, 6)}, fp)
+	if len(got) != 1 {
+		t.Errorf("Filter kept %d diagnostics, want 1", len(got))
+	}
+}
+
+func TestLoadVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "entries": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted an unsupported version")
+	}
+}
@@ -0,0 +1,161 @@
+package baseline
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+// maxSpanLines caps how much of a multi-line diagnostic contributes its
+// source text to the fingerprint.
+const maxSpanLines = 8
+
+// Fingerprint identifies a diagnostic independently of its position.
+type Fingerprint struct {
+	Hash     string
+	File     string
+	Function string
+}
+
+// Fingerprinter computes fingerprints, caching the parsed source of each
+// file it has seen.
+type Fingerprinter struct {
+	root string
+
+	mu    sync.Mutex
+	files map[string]*fileInfo
+}
+
+type fileInfo struct {
+	lines [][]byte
+	funcs []funcSpan
+}
+
+type funcSpan struct {
+	name       string
+	start, end int
+}
+
+// NewFingerprinter returns a Fingerprinter that records file paths
+// relative to root, so baselines can be shared between checkouts.
+func NewFingerprinter(root string) *Fingerprinter {
+	return &Fingerprinter{root: root, files: make(map[string]*fileInfo)}
+}
+
+// Fingerprint hashes the rule, relative file path, enclosing function and
+// whitespace-normalized source text of d.
+func (fp *Fingerprinter) Fingerprint(d rule.Diagnostic) Fingerprint {
+	file := d.Pos.Filename
+	if rel, err := filepath.Rel(fp.root, file); err == nil && !strings.HasPrefix(rel, "..") {
+		file = rel
+	}
+	file = filepath.ToSlash(file)
+
+	info := fp.load(d.Pos.Filename)
+
+	var fn, text string
+	if info != nil {
+		fn = info.enclosingFunc(d.Pos.Line)
+		text = info.normalizedText(d.Pos.Line, d.End.Line)
+	}
+
+	h := sha256.New()
+	for _, part := range []string{d.Rule, file, fn, text} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return Fingerprint{
+		Hash:     hex.EncodeToString(h.Sum(nil)[:16]),
+		File:     file,
+		Function: fn,
+	}
+}
+
+func (fp *Fingerprinter) load(path string) *fileInfo {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	if info, ok := fp.files[path]; ok {
+		return info
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fp.files[path] = nil
+		return nil
+	}
+
+	info := &fileInfo{lines: bytes.Split(src, []byte("\n"))}
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if f != nil {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			info.funcs = append(info.funcs, funcSpan{
+				name:  funcName(fd),
+				start: fset.Position(fd.Pos()).Line,
+				end:   fset.Position(fd.End()).Line,
+			})
+		}
+	}
+	fp.files[path] = info
+	return info
+}
+
+func (info *fileInfo) enclosingFunc(line int) string {
+	for _, fn := range info.funcs {
+		if line >= fn.start && line <= fn.end {
+			return fn.name
+		}
+	}
+	return ""
+}
+
+func (info *fileInfo) normalizedText(from, to int) string {
+	if to < from {
+		to = from
+	}
+	if to-from >= maxSpanLines {
+		to = from + maxSpanLines - 1
+	}
+	var parts []string
+	for line := from; line <= to; line++ {
+		if line < 1 || line > len(info.lines) {
+			break
+		}
+		parts = append(parts, strings.Fields(string(info.lines[line-1]))...)
+	}
+	return strings.Join(parts, " ")
+}
+
+func funcName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return fd.Name.Name
+	}
+	recv := fd.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if idx, ok := recv.(*ast.IndexExpr); ok {
+		recv = idx.X
+	}
+	if idx, ok := recv.(*ast.IndexListExpr); ok {
+		recv = idx.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fd.Name.Name
+	}
+	return fd.Name.Name
+}
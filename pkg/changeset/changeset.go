@@ -0,0 +1,225 @@
+package changeset
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+// Set records which lines of which files were changed. Files added
+// wholesale, such as untracked files, count as changed on every line.
+type Set struct {
+	lines map[string]map[int]bool
+	whole map[string]bool
+}
+
+func newSet() *Set {
+	return &Set{
+		lines: make(map[string]map[int]bool),
+		whole: make(map[string]bool),
+	}
+}
+
+// ParsePatch reads a unified diff. Paths in the diff are resolved against
+// root; git's a/ and b/ prefixes are stripped.
+func ParsePatch(r io.Reader, root string) (*Set, error) {
+	s := newSet()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var (
+		file             string
+		newLine          int
+		oldLeft, newLeft int
+	)
+	for sc.Scan() {
+		line := sc.Text()
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				if file != "" {
+					s.add(file, newLine)
+				}
+				newLine++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file"
+			default:
+				newLine++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = patchPath(strings.TrimPrefix(line, "+++ "), root)
+		case strings.HasPrefix(line, "@@"):
+			h, err := parseHunk(line)
+			if err != nil {
+				return nil, err
+			}
+			newLine, oldLeft, newLeft = h.newStart, h.oldCount, h.newCount
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading patch: %w", err)
+	}
+	return s, nil
+}
+
+// FromRevision collects changes between rev and the working tree,
+// including uncommitted edits and untracked files, by running git in dir.
+func FromRevision(dir, rev string) (*Set, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(top))
+
+	diff, err := git(root, "diff", "--no-color", "--no-ext-diff", "-U0", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParsePatch(bytes.NewReader(diff), root)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := git(root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range strings.Split(string(untracked), "\n") {
+		if p != "" {
+			s.whole[filepath.Join(root, filepath.FromSlash(p))] = true
+		}
+	}
+	return s, nil
+}
+
+// HasFile reports whether any line of path changed.
+func (s *Set) HasFile(path string) bool {
+	return s.whole[path] || len(s.lines[path]) > 0
+}
+
+// Intersects reports whether the lines spanned by d include a change.
+func (s *Set) Intersects(d rule.Diagnostic) bool {
+	path := d.Pos.Filename
+	if s.whole[path] {
+		return true
+	}
+	changed := s.lines[path]
+	if len(changed) == 0 {
+		return false
+	}
+	end := d.End.Line
+	if end < d.Pos.Line || d.End.Filename != path {
+		end = d.Pos.Line
+	}
+	for line := d.Pos.Line; line <= end; line++ {
+		if changed[line] {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the diagnostics that intersect a change.
+func (s *Set) Filter(diags []rule.Diagnostic) []rule.Diagnostic {
+	out := make([]rule.Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		if s.Intersects(d) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func (s *Set) add(file string, line int) {
+	m := s.lines[file]
+	if m == nil {
+		m = make(map[int]bool)
+		s.lines[file] = m
+	}
+	m[line] = true
+}
+
+func patchPath(name, root string) string {
+	if i := strings.IndexByte(name, '\t'); i >= 0 {
+		name = name[:i] // timestamps written by diff(1)
+	}
+	if name == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(name, `"`) {
+		// git quotes names with unusual characters, C-style.
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+	}
+	name = strings.TrimPrefix(name, "b/")
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(root, filepath.FromSlash(name))
+}
+
+type hunk struct {
+	newStart           int
+	oldCount, newCount int
+}
+
+// parseHunk parses a "@@ -a,b +c,d @@" header; omitted counts are 1.
+func parseHunk(header string) (hunk, error) {
+	var h hunk
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return h, fmt.Errorf("malformed hunk header %q", header)
+	}
+	_, oldCount, err := parseRange(fields[1][1:])
+	if err != nil {
+		return h, fmt.Errorf("malformed hunk header %q", header)
+	}
+	newStart, newCount, err := parseRange(fields[2][1:])
+	if err != nil {
+		return h, fmt.Errorf("malformed hunk header %q", header)
+	}
+	return hunk{newStart: newStart, oldCount: oldCount, newCount: newCount}, nil
+}
+
+func parseRange(s string) (start, count int, err error) {
+	num, cnt, hasCount := strings.Cut(s, ",")
+	if start, err = strconv.Atoi(num); err != nil {
+		return 0, 0, err
+	}
+	count = 1
+	if hasCount {
+		if count, err = strconv.Atoi(cnt); err != nil {
+			return 0, 0, err
+		}
+	}
+	return start, count, nil
+}
+
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
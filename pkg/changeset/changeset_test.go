@@ -0,0 +1,247 @@
+package changeset
+
+import (
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+const root = "/repo"
+
+// changed returns the changed lines of s by file, relative to root.
+func changed(s *Set) map[string][]int {
+	out := make(map[string][]int)
+	for file, lines := range s.lines {
+		rel, _ := filepath.Rel(root, file)
+		for line := range lines {
+			out[filepath.ToSlash(rel)] = append(out[filepath.ToSlash(rel)], line)
+		}
+		slices.Sort(out[filepath.ToSlash(rel)])
+	}
+	return out
+}
+
+func TestParsePatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  map[string][]int
+	}{
+		{
+			name: "modification",
+			patch: `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -3,4 +3,5 @@ func f() {
+ 	x := 1
+-	y := 2
++	y := 3
++	z := 4
+ 	return
+ }
+`,
+			want: map[string][]int{"a.go": {4, 5}},
+		},
+		{
+			name: "multiple hunks and files",
+			patch: `--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+-package a
++package b
+
+@@ -10,2 +10,3 @@
+ x
++y
+ z
+--- a/sub/b.go
++++ b/sub/b.go
+@@ -7,0 +8,2 @@
++one
++two
+`,
+			want: map[string][]int{"a.go": {1, 11}, "sub/b.go": {8, 9}},
+		},
+		{
+			name: "zero context",
+			patch: `--- a/a.go
++++ b/a.go
+@@ -5 +5 @@
+-old
++new
+@@ -9,2 +8,0 @@
+-gone
+-gone
+@@ -20 +19,2 @@
+-old
++new
++added
+`,
+			want: map[string][]int{"a.go": {5, 19, 20}},
+		},
+		{
+			name: "new file",
+			patch: `diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,3 @@
++package p
++
++var x = 1
+`,
+			want: map[string][]int{"new.go": {1, 2, 3}},
+		},
+		{
+			name: "deletion",
+			patch: `diff --git a/old.go b/old.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package p
+-var x = 1
+--- a/a.go
++++ b/a.go
+@@ -1 +1 @@
+-x
++y
+`,
+			want: map[string][]int{"a.go": {1}},
+		},
+		{
+			name: "rename",
+			patch: `diff --git a/old.go b/new.go
+similarity index 90%
+rename from old.go
+rename to new.go
+index 1111111..2222222 100644
+--- a/old.go
++++ b/new.go
+@@ -1,3 +1,3 @@
+ package p
+-var x = 1
++var x = 2
+ var y = 1
+diff --git a/same.go b/moved.go
+similarity index 100%
+rename from same.go
+rename to moved.go
+`,
+			want: map[string][]int{"new.go": {2}},
+		},
+		{
+			name: "no newline at end of file",
+			patch: `--- a/a.go
++++ b/a.go
+@@ -1,2 +1,2 @@
+ package p
+-var x = 1
+\ No newline at end of file
++var x = 2
+\ No newline at end of file
+--- a/b.go
++++ b/b.go
+@@ -1 +1,2 @@
+ package p
++var y = 1
+`,
+			want: map[string][]int{"a.go": {2}, "b.go": {2}},
+		},
+		{
+			name: "removed lines that look like headers",
+			patch: `--- a/a.go
++++ b/a.go
+@@ -1,3 +1,2 @@
+ x
+--- comment
+-++ other
++++ added
+`,
+			want: map[string][]int{"a.go": {2}},
+		},
+		{
+			name: "diff(1) timestamps and absolute paths",
+			patch: "--- /repo/a.go\t2024-01-01 00:00:00.000000000 +0000\n" +
+				"+++ /repo/a.go\t2024-01-02 00:00:00.000000000 +0000\n" +
+				"@@ -2 +2 @@\n-x\n+y\n",
+			want: map[string][]int{"a.go": {2}},
+		},
+		{
+			name: "quoted path",
+			patch: `diff --git "a/caf\303\251.go" "b/caf\303\251.go"
+--- "a/caf\303\251.go"
++++ "b/caf\303\251.go"
+@@ -1 +1 @@
+-x
++y
+`,
+			want: map[string][]int{"café.go": {1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParsePatch(strings.NewReader(tt.patch), root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := changed(s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changed lines %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePatchMalformedHunk(t *testing.T) {
+	for _, header := range []string{"@@ -1,2 @@", "@@ -x +1 @@", "@@ -1 +1,y @@", "@@ 1 2 @@"} {
+		patch := "--- a/a.go\n+++ b/a.go\n" + header + "\n+x\n"
+		if _, err := ParsePatch(strings.NewReader(patch), root); err == nil {
+			t.Errorf("ParsePatch accepted hunk header %q", header)
+		}
+	}
+}
+
+func TestIntersects(t *testing.T) {
+	s, err := ParsePatch(strings.NewReader("--- a/a.go\n+++ b/a.go\n@@ -5 +5,2 @@\n-x\n+y\n+z\n"), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.whole["/repo/new.go"] = true
+
+	at := func(file string, line, end int) rule.Diagnostic {
+		d := rule.Diagnostic{Pos: token.Position{Filename: file, Line: line}}
+		if end > 0 {
+			d.End = token.Position{Filename: file, Line: end}
+		}
+		return d
+	}
+	tests := []struct {
+		d    rule.Diagnostic
+		want bool
+	}{
+		{at("/repo/a.go", 5, 0), true},
+		{at("/repo/a.go", 6, 0), true},
+		{at("/repo/a.go", 4, 0), false},
+		{at("/repo/a.go", 7, 0), false},
+		{at("/repo/a.go", 2, 5), true}, // spans a changed line
+		{at("/repo/a.go", 2, 4), false},
+		{at("/repo/b.go", 5, 0), false},
+		{at("/repo/new.go", 100, 0), true},
+	}
+	for _, tt := range tests {
+		if got := s.Intersects(tt.d); got != tt.want {
+			t.Errorf("Intersects(%s:%d-%d) = %v, want %v", tt.d.Pos.Filename, tt.d.Pos.Line, tt.d.End.Line, got, tt.want)
+		}
+	}
+	if !s.HasFile("/repo/a.go") || !s.HasFile("/repo/new.go") || s.HasFile("/repo/b.go") {
+		t.Error("HasFile reports the wrong files")
+	}
+}
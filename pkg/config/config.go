@@ -5,65 +5,270 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Rules       map[string]RuleConfig `yaml:"rules"`
-	Cache       CacheConfig           `yaml:"cache"`
-	Output      OutputConfig          `yaml:"output"`
-	Concurrency int                   `yaml:"concurrency"`
-	EnableAll   bool                  `yaml:"enable_all"`
+	Rules        map[string]RuleConfig `yaml:"rules"`
+	Cache        CacheConfig           `yaml:"cache"`
+	Output       OutputConfig          `yaml:"output"`
+	Suppressions SuppressionConfig     `yaml:"suppressions"`
+	Load         LoadConfig            `yaml:"load"`
+	Concurrency  int                   `yaml:"concurrency"`
+	EnableAll    bool                  `yaml:"enable_all"`
+
+	// Presets, EnableCategories and EnableTags enable the rules they
+	// select on top of EnableAll, and DisableCategories and DisableTags
+	// turn rules off again. An entry in Rules overrides them all.
+	Presets           []string `yaml:"presets,omitempty"`
+	EnableCategories  []string `yaml:"enable_categories,omitempty"`
+	DisableCategories []string `yaml:"disable_categories,omitempty"`
+	EnableTags        []string `yaml:"enable_tags,omitempty"`
+	DisableTags       []string `yaml:"disable_tags,omitempty"`
+
+	// Include, when set, limits linting to the files matching one of its
+	// patterns, and Exclude skips the files matching any of its patterns.
+	// Paths are relative to the working directory; see package glob for
+	// the syntax.
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
+	// LintGenerated lints files marked "Code generated ... DO NOT EDIT.",
+	// which are skipped by default.
+	LintGenerated bool `yaml:"lint_generated"`
+
+	// Sources records where each setting came from.
+	Sources Sources `yaml:"-"`
+	// overrides holds the settings changed by Set, as config fragments.
+	overrides []map[string]any
 }
 
 type RuleConfig struct {
-	Enabled  bool              `yaml:"enabled"`
-	Severity string            `yaml:"severity,omitempty"`
-	Options  map[string]any    `yaml:"options,omitempty"`
+	// Enabled turns the rule on or off regardless of the selectors such as
+	// enable_all and presets. A rule that a config file lists without it
+	// is off; nil, from an override of another of the rule's settings,
+	// leaves the choice to the selectors.
+	Enabled  *bool          `yaml:"enabled,omitempty"`
+	Severity string         `yaml:"severity,omitempty"`
+	Options  map[string]any `yaml:"options,omitempty"`
+	// Include and Exclude narrow the files the rule reports on, like
+	// their global counterparts.
+	Include []string `yaml:"include,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty"`
 }
 
 type CacheConfig struct {
 	Enabled bool   `yaml:"enabled"`
 	Dir     string `yaml:"dir"`
+	// Backend selects the storage: "pack", a single file read once per
+	// run, or "dir", one file per entry.
+	Backend string `yaml:"backend"`
+	// MaxSize bounds the cache directory, e.g. "512MB"; least recently
+	// used entries are evicted beyond it. Empty or "0" means unbounded.
+	MaxSize string `yaml:"max_size"`
+	// MaxAge evicts entries not used for this long, e.g. "720h". Zero
+	// keeps entries regardless of age.
+	MaxAge time.Duration `yaml:"max_age"`
+	// Remote shares entries with other machines through an HTTP cache
+	// server, on top of the local cache.
+	Remote RemoteCacheConfig `yaml:"remote,omitempty"`
+}
+
+type RemoteCacheConfig struct {
+	// URL is the server's base URL; empty disables the remote cache.
+	URL string `yaml:"url"`
+	// Layout is "gradle", the default, for /cache/<key>, or "bazel" for
+	// /ac/<key>, which stock Bazel caches only accept with action-cache
+	// validation turned off.
+	Layout string `yaml:"layout"`
+	// ReadOnly fetches entries without uploading new ones.
+	ReadOnly bool          `yaml:"read_only"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// MaxBytes parses MaxSize.
+func (c CacheConfig) MaxBytes() (int64, error) {
+	return ParseSize(c.MaxSize)
+}
+
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"KB", 1 << 10},
+	{"MB", 1 << 20},
+	{"GB", 1 << 30},
+	{"K", 1 << 10},
+	{"M", 1 << 20},
+	{"G", 1 << 30},
+	{"B", 1},
+}
+
+// ParseSize parses a byte count with an optional binary unit suffix: B,
+// K/KB, M/MB or G/GB. The empty string is 0.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	upper := strings.ToUpper(s)
+	factor := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(upper, u.suffix) {
+			factor = u.factor
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, u.suffix))
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * factor, nil
+}
+
+type LoadConfig struct {
+	// Tolerant lints every package that loads, reporting load and type
+	// errors as "typecheck" diagnostics; packages with type errors only
+	// get the rules that need no type information. When false, any
+	// package error aborts the run.
+	Tolerant bool `yaml:"tolerant"`
+	// Tests includes _test.go files and external test packages.
+	Tests bool `yaml:"tests"`
+
+	BuildConfig `yaml:",inline"`
+	// Matrix loads the packages once per entry instead, so that files
+	// behind other build constraints are linted too. Entries add their
+	// tags to Tags and default to GOOS and GOARCH.
+	Matrix []BuildConfig `yaml:"matrix,omitempty"`
+}
+
+// BuildConfig selects the files of a build: empty fields mean the
+// defaults of the go command.
+type BuildConfig struct {
+	Tags   []string `yaml:"tags,omitempty"`
+	GOOS   string   `yaml:"goos,omitempty"`
+	GOARCH string   `yaml:"goarch,omitempty"`
+}
+
+func (b BuildConfig) String() string {
+	goos, goarch := b.GOOS, b.GOARCH
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	s := goos + "/" + goarch
+	if len(b.Tags) > 0 {
+		s += " tags=" + strings.Join(b.Tags, ",")
+	}
+	return s
+}
+
+// Builds returns the build configurations to load packages under.
+func (c LoadConfig) Builds() []BuildConfig {
+	if len(c.Matrix) == 0 {
+		return []BuildConfig{c.BuildConfig}
+	}
+	out := make([]BuildConfig, len(c.Matrix))
+	for i, m := range c.Matrix {
+		b := BuildConfig{
+			Tags:   append(append([]string(nil), c.Tags...), m.Tags...),
+			GOOS:   m.GOOS,
+			GOARCH: m.GOARCH,
+		}
+		if b.GOOS == "" {
+			b.GOOS = c.GOOS
+		}
+		if b.GOARCH == "" {
+			b.GOARCH = c.GOARCH
+		}
+		out[i] = b
+	}
+	return out
+}
+
+type SuppressionConfig struct {
+	// ReportUnused reports //glint:ignore and //nolint directives that
+	// no longer silence any diagnostic.
+	ReportUnused bool `yaml:"report_unused"`
 }
 
 type OutputConfig struct {
 	Format string `yaml:"format"`
 	Color  bool   `yaml:"color"`
+	// FailOn is the lowest severity of the issues that fail the run:
+	// "error", "warning", "info" or "none". It only applies to lint
+	// findings: packages that fail to type-check always fail the run, with
+	// a different exit code.
+	FailOn string `yaml:"fail_on"`
+	// MaxIssues is how many issues at FailOn or above are tolerated
+	// before the run fails.
+	MaxIssues int `yaml:"max_issues"`
+	// Outputs, when set, replaces Format with several reports of the
+	// same run, such as text on standard output and SARIF in a file.
+	Outputs []OutputTarget `yaml:"outputs,omitempty"`
 }
 
-func DefaultConfig() *Config {
-	home, _ := os.UserHomeDir()
-	return &Config{
-		Rules:       make(map[string]RuleConfig),
-		Cache:       CacheConfig{Enabled: true, Dir: filepath.Join(home, ".cache", "glint")},
-		Output:      OutputConfig{Format: "text", Color: true},
-		Concurrency: runtime.NumCPU(),
-		EnableAll:   true,
-	}
+// OutputTarget is one report of a run: Format written to Path, or to
+// standard output when Path is empty.
+type OutputTarget struct {
+	Format string `yaml:"format"`
+	Path   string `yaml:"path,omitempty"`
 }
 
-var configNames = []string{".glint.yml", ".glint.yaml", "glint.yml", "glint.yaml"}
+// Targets returns the reports to write.
+func (c OutputConfig) Targets() []OutputTarget {
+	if len(c.Outputs) > 0 {
+		return c.Outputs
+	}
+	return []OutputTarget{{Format: c.Format}}
+}
 
-func Load(dir string) (*Config, error) {
-	for _, name := range configNames {
-		path := filepath.Join(dir, name)
-		data, err := os.ReadFile(path)
-		if err != nil {
-			continue
+// ParseOutputs parses a comma-separated list of outputs, each a format
+// alone for standard output or "format:path", as in
+// "text,sarif:glint.sarif".
+func ParseOutputs(s string) ([]OutputTarget, error) {
+	var out []OutputTarget
+	stdout := 0
+	for _, e := range strings.Split(s, ",") {
+		format, path, _ := strings.Cut(strings.TrimSpace(e), ":")
+		if !slices.Contains(enums["output.format"], format) {
+			return nil, fmt.Errorf("output %q: unknown format %q (valid: %s)", e, format, strings.Join(enums["output.format"], ", "))
 		}
-		return parse(data)
+		if path == "" {
+			stdout++
+		}
+		out = append(out, OutputTarget{Format: format, Path: path})
+	}
+	if stdout > 1 {
+		return nil, fmt.Errorf("outputs %q: at most one can go to standard output", s)
 	}
-	return DefaultConfig(), nil
+	return out, nil
 }
 
-func LoadFile(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("reading config: %w", err)
+func DefaultConfig() *Config {
+	home, _ := os.UserHomeDir()
+	return &Config{
+		Rules: make(map[string]RuleConfig),
+		Cache: CacheConfig{
+			Enabled: true,
+			Dir:     filepath.Join(home, ".cache", "glint"),
+			Backend: "pack",
+			MaxSize: "512MB",
+			MaxAge:  30 * 24 * time.Hour,
+		},
+		Output:      OutputConfig{Format: "text", Color: true, FailOn: "info"},
+		Load:        LoadConfig{Tolerant: true, Tests: true},
+		Concurrency: runtime.NumCPU(),
+		EnableAll:   true,
+		Sources:     make(Sources),
 	}
-	return parse(data)
 }
 
 func parse(data []byte) (*Config, error) {
@@ -74,9 +279,11 @@ func parse(data []byte) (*Config, error) {
 	if cfg.Concurrency <= 0 {
 		cfg.Concurrency = runtime.NumCPU()
 	}
+	home, _ := os.UserHomeDir()
 	if cfg.Cache.Dir == "" {
-		home, _ := os.UserHomeDir()
 		cfg.Cache.Dir = filepath.Join(home, ".cache", "glint")
+	} else if rest, ok := strings.CutPrefix(cfg.Cache.Dir, "~/"); ok {
+		cfg.Cache.Dir = filepath.Join(home, rest)
 	}
 	return cfg, nil
 }
@@ -84,17 +291,18 @@ func parse(data []byte) (*Config, error) {
 func WriteDefault(path string) error {
 	cfg := DefaultConfig()
 	cfg.EnableAll = false
+	on := true
 	cfg.Rules = map[string]RuleConfig{
-		"unchecked-error":        {Enabled: true, Severity: "error"},
-		"nil-deref":              {Enabled: true, Severity: "error"},
-		"shadow-var":             {Enabled: true, Severity: "warning"},
-		"naming-convention":      {Enabled: true, Severity: "warning"},
-		"import-order":           {Enabled: true, Severity: "info"},
-		"line-length":            {Enabled: true, Severity: "warning", Options: map[string]any{"max": 120}},
-		"prealloc-slice":         {Enabled: true, Severity: "warning"},
-		"unnecessary-conversion": {Enabled: true, Severity: "warning"},
-		"hardcoded-secret":       {Enabled: true, Severity: "error"},
-		"sql-injection":          {Enabled: true, Severity: "error"},
+		"unchecked-error":        {Enabled: &on, Severity: "error"},
+		"nil-deref":              {Enabled: &on, Severity: "error"},
+		"shadow-var":             {Enabled: &on, Severity: "warning"},
+		"naming-convention":      {Enabled: &on, Severity: "warning"},
+		"import-order":           {Enabled: &on, Severity: "info"},
+		"line-length":            {Enabled: &on, Severity: "warning", Options: map[string]any{"max": 120}},
+		"prealloc-slice":         {Enabled: &on, Severity: "warning"},
+		"unnecessary-conversion": {Enabled: &on, Severity: "warning"},
+		"hardcoded-secret":       {Enabled: &on, Severity: "error"},
+		"sql-injection":          {Enabled: &on, Severity: "error"},
 	}
 
 	data, err := yaml.Marshal(cfg)
@@ -0,0 +1,380 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/nicholas/glint/pkg/glob"
+	"github.com/nicholas/glint/pkg/rule"
+	"gopkg.in/yaml.v3"
+)
+
+var configNames = []string{".glint.yml", ".glint.yaml", "glint.yml", "glint.yaml"}
+
+// Load returns the config in effect in dir: the config files of the
+// directories from the module root down to dir, merged so that the
+// nearest file wins. Without any config file it returns the defaults.
+func Load(dir string) (*Config, error) {
+	merged := make(map[string]any)
+	sources := make(Sources)
+	found := false
+	for _, d := range moduleDirs(dir) {
+		path := findFile(d)
+		if path == "" {
+			continue
+		}
+		raw, err := readRaw(path, nil, sources)
+		if err != nil {
+			return nil, err
+		}
+		merge(merged, raw)
+		found = true
+	}
+	if !found {
+		return DefaultConfig(), nil
+	}
+	disableListed(merged, merged)
+	return decode(merged, sources)
+}
+
+// LoadFile reads the config file at path, along with the files it
+// extends.
+func LoadFile(path string) (*Config, error) {
+	sources := make(Sources)
+	raw, err := readRaw(path, nil, sources)
+	if err != nil {
+		return nil, err
+	}
+	disableListed(raw, raw)
+	return decode(raw, sources)
+}
+
+// ValidateFile checks the config file at path and the files it extends,
+// returning an ErrorList with the position of every problem found.
+func ValidateFile(path string) error {
+	_, err := readRaw(path, nil, nil)
+	return err
+}
+
+// Files returns the config files that apply in dir, from the module root
+// down, followed by those in the directories below dir that the go
+// command would consider.
+func Files(dir string) ([]string, error) {
+	var files []string
+	for _, d := range moduleDirs(dir) {
+		if path := findFile(d); path != "" {
+			files = append(files, path)
+		}
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || path == root {
+			return nil
+		}
+		name := d.Name()
+		if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "vendor" {
+			return filepath.SkipDir
+		}
+		if f := findFile(path); f != "" {
+			files = append(files, f)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// findFile returns the path of the config file in dir, or "".
+func findFile(dir string) string {
+	for _, name := range configNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// moduleDirs returns the directories from the root of the module
+// containing dir down to dir itself, or just dir outside a module.
+func moduleDirs(dir string) []string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return []string{dir}
+	}
+	dirs := []string{dir}
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			slices.Reverse(dirs)
+			return dirs
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return []string{dir}
+		}
+		d = parent
+		dirs = append(dirs, d)
+	}
+}
+
+// readRaw reads the config file at path into a generic map, with the
+// files named by its extends key merged underneath. stack holds the files
+// being read, to detect cycles. The file of each value is recorded in
+// sources, if it is non-nil.
+func readRaw(path string, stack []string, sources Sources) (map[string]any, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if slices.Contains(stack, path) {
+		return nil, fmt.Errorf("config %s: extends cycle: %s", path, strings.Join(append(stack, path), " -> "))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	if err := validate(path, data, rule.GlobalRegistry()); err != nil {
+		return nil, err
+	}
+	raw := make(map[string]any)
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	ext, ok := raw["extends"]
+	if !ok {
+		sources.record(raw, path, "")
+		return raw, nil
+	}
+	delete(raw, "extends")
+	var bases []string
+	switch v := ext.(type) {
+	case string:
+		bases = []string{v}
+	case []any:
+		for _, b := range v {
+			s, ok := b.(string)
+			if !ok {
+				return nil, fmt.Errorf("config %s: extends: want a path or a list of paths", path)
+			}
+			bases = append(bases, s)
+		}
+	default:
+		return nil, fmt.Errorf("config %s: extends: want a path or a list of paths", path)
+	}
+
+	// Later bases override earlier ones, and the file itself all of them.
+	merged := make(map[string]any)
+	for _, b := range bases {
+		if rest, ok := strings.CutPrefix(b, "~/"); ok {
+			home, _ := os.UserHomeDir()
+			b = filepath.Join(home, rest)
+		} else if !filepath.IsAbs(b) {
+			b = filepath.Join(filepath.Dir(path), b)
+		}
+		base, err := readRaw(b, append(stack, path), sources)
+		if err != nil {
+			return nil, err
+		}
+		merge(merged, base)
+	}
+	merge(merged, raw)
+	sources.record(raw, path, "")
+	return merged, nil
+}
+
+// merge copies src into dst. Nested maps are merged key by key; other
+// values, including lists, replace those in dst.
+func merge(dst, src map[string]any) {
+	for k, v := range src {
+		sm, srcMap := v.(map[string]any)
+		dm, dstMap := dst[k].(map[string]any)
+		if srcMap && dstMap {
+			merge(dm, sm)
+			continue
+		}
+		if srcMap {
+			v = clone(sm)
+		}
+		dst[k] = v
+	}
+}
+
+// disableListed turns off the rules that file, a config file's raw
+// contents, lists without saying whether they are enabled, unless merged,
+// the config it was merged into, says so. In config files such an entry
+// has always meant the rule is off; only the entries that overrides add
+// leave the rule's selection to enable_all, presets and the like.
+func disableListed(merged, file map[string]any) {
+	listed, _ := file["rules"].(map[string]any)
+	rules, _ := merged["rules"].(map[string]any)
+	for name := range listed {
+		if rules[name] == nil {
+			rules[name] = make(map[string]any) // a bare "name:" entry
+		}
+		rc, ok := rules[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, ok := rc["enabled"]; !ok {
+			rc["enabled"] = false
+		}
+	}
+}
+
+func clone(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	merge(out, m)
+	return out
+}
+
+func decode(raw map[string]any, sources Sources) (*Config, error) {
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	cfg, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if sources != nil {
+		cfg.Sources = sources
+	}
+	return cfg, nil
+}
+
+// Tree resolves the config in effect in the directories below Root: Base,
+// with the config files of the directories between Root and each one
+// merged on top, nearest last, and then Base's overrides. Only the rule
+// selection, include/exclude and generated-file settings of those files
+// take effect. Their include and exclude patterns are relative to the
+// file's directory.
+type Tree struct {
+	Root string
+	Base *Config
+
+	mu   sync.Mutex
+	base map[string]any
+	raws map[string]map[string]any // by dir; nil without a config file
+	dirs map[string]*Config        // by the nearest dir with a config file
+}
+
+func NewTree(root string, base *Config) *Tree {
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &Tree{
+		Root: root,
+		Base: base,
+		raws: make(map[string]map[string]any),
+		dirs: make(map[string]*Config),
+	}
+}
+
+// ForDir returns the config in effect in dir. Directories that no config
+// file below Root applies to get Base itself, and those sharing their
+// nearest config file share the same *Config.
+func (t *Tree) ForDir(dir string) (*Config, error) {
+	rel, err := filepath.Rel(t.Root, dir)
+	if err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return t.Base, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var (
+		chain   []map[string]any
+		nearest string
+	)
+	d := t.Root
+	for _, elem := range strings.Split(rel, string(filepath.Separator)) {
+		d = filepath.Join(d, elem)
+		raw, err := t.raw(d)
+		if err != nil {
+			return nil, err
+		}
+		if raw != nil {
+			chain = append(chain, raw)
+			nearest = d
+		}
+	}
+	if len(chain) == 0 {
+		return t.Base, nil
+	}
+	if cfg, ok := t.dirs[nearest]; ok {
+		return cfg, nil
+	}
+
+	if t.base == nil {
+		if t.base, err = toRaw(t.Base); err != nil {
+			return nil, err
+		}
+	}
+	merged := clone(t.base)
+	for _, raw := range chain {
+		merge(merged, raw)
+	}
+	for _, raw := range chain {
+		disableListed(merged, raw)
+	}
+	// Environment and command-line overrides win over every file.
+	for _, o := range t.Base.overrides {
+		merge(merged, o)
+	}
+	cfg, err := decode(merged, nil)
+	if err != nil {
+		return nil, fmt.Errorf("config for %s: %w", nearest, err)
+	}
+	t.dirs[nearest] = cfg
+	return cfg, nil
+}
+
+// raw returns the contents of dir's config file, or nil if it has none.
+func (t *Tree) raw(dir string) (map[string]any, error) {
+	if raw, ok := t.raws[dir]; ok {
+		return raw, nil
+	}
+	var raw map[string]any
+	if path := findFile(dir); path != "" {
+		var err error
+		if raw, err = readRaw(path, nil, nil); err != nil {
+			return nil, err
+		}
+		rel, _ := filepath.Rel(t.Root, dir)
+		rebase(raw, filepath.ToSlash(rel))
+	}
+	t.raws[dir] = raw
+	return raw, nil
+}
+
+// rebase rewrites the include and exclude patterns of raw, global and per
+// rule, from relative to dir to relative to the root.
+func rebase(raw map[string]any, dir string) {
+	patterns := func(m map[string]any) {
+		for _, key := range []string{"include", "exclude"} {
+			list, _ := m[key].([]any)
+			for i, p := range list {
+				if s, ok := p.(string); ok {
+					list[i] = glob.Under(dir, s)
+				}
+			}
+		}
+	}
+	patterns(raw)
+	rules, _ := raw["rules"].(map[string]any)
+	for _, rc := range rules {
+		if m, ok := rc.(map[string]any); ok {
+			patterns(m)
+		}
+	}
+}
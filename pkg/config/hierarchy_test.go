@@ -0,0 +1,65 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRebase(t *testing.T) {
+	raw := map[string]any{
+		"include": []any{"cmd/", "*.go"},
+		"exclude": []any{"gen/x.go", "testdata", 42},
+		"rules": map[string]any{
+			"line-length": map[string]any{
+				"exclude": []any{"**/mocks/*.go", "vendor/"},
+			},
+			"shadow-var": map[string]any{"severity": "info"},
+			"nil-deref":  nil,
+		},
+	}
+	rebase(raw, "a/b")
+
+	want := map[string]any{
+		"include": []any{"a/b/**/cmd/", "a/b/**/*.go"},
+		"exclude": []any{"a/b/gen/x.go", "a/b/**/testdata", 42},
+		"rules": map[string]any{
+			"line-length": map[string]any{
+				"exclude": []any{"a/b/**/mocks/*.go", "a/b/**/vendor/"},
+			},
+			"shadow-var": map[string]any{"severity": "info"},
+			"nil-deref":  nil,
+		},
+	}
+	if !reflect.DeepEqual(raw, want) {
+		t.Errorf("rebase:\n%v\nwant:\n%v", raw, want)
+	}
+}
+
+func TestTreeRebasesPatterns(t *testing.T) {
+	root := t.TempDir()
+	path := writeConfig(t, root, "exclude: [testdata]\n")
+	writeConfig(t, filepath.Join(root, "a", "b"), `exclude: [gen/]
+rules:
+  line-length:
+    include: ["*.go"]
+`)
+	base, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := NewTree(root, base).ForDir(filepath.Join(root, "a", "b", "c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Lists replace those of the files above.
+	if want := []string{"a/b/**/gen/"}; !reflect.DeepEqual(cfg.Exclude, want) {
+		t.Errorf("Exclude = %q, want %q", cfg.Exclude, want)
+	}
+	if got, want := cfg.Rules["line-length"].Include, []string{"a/b/**/*.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("line-length Include = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(base.Exclude, []string{"testdata"}) {
+		t.Errorf("root Exclude = %q, want it unchanged", base.Exclude)
+	}
+}
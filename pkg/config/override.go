@@ -0,0 +1,349 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicholas/glint/pkg/rule"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the names of the environment variables that override
+// settings.
+const EnvPrefix = "GLINT_"
+
+// Set overrides the setting at the dotted key path, such as "cache.dir" or
+// "rules.line-length.options.max", with value in the string form used by
+// environment variables and flags: lists are comma-separated. value is
+// converted to the setting's type and checked like the config files are.
+// source, such as "flag --set", is recorded in Sources.
+//
+// Overrides also apply on top of the config files below the working
+// directory; see Tree.
+func (c *Config) Set(key, value, source string) error {
+	s, err := lookup(key, rule.GlobalRegistry())
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	v, err := s.coerce(value)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", source, key, err)
+	}
+	if err := s.check(v, source); err != nil {
+		return err
+	}
+
+	fragment := make(map[string]any)
+	m := fragment
+	parts := strings.Split(key, ".")
+	for _, p := range parts[:len(parts)-1] {
+		sub := make(map[string]any)
+		m[p] = sub
+		m = sub
+	}
+	m[parts[len(parts)-1]] = v
+
+	raw, err := toRaw(c)
+	if err != nil {
+		return err
+	}
+	merge(raw, fragment)
+	cfg, err := decode(raw, c.Sources)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	cfg.overrides = append(c.overrides, fragment)
+	*c = *cfg
+	c.Sources.Set(key, source)
+	return nil
+}
+
+// ApplyEnv overrides settings from the GLINT_* variables of environ, in
+// the "name=value" form of os.Environ. A variable is named after the key
+// of its setting, upper-cased, with dots and dashes replaced by
+// underscores: GLINT_CACHE_MAX_SIZE sets cache.max_size and
+// GLINT_RULES_LINE_LENGTH_OPTIONS_MAX sets rules.line-length.options.max.
+//
+// Variables that name no setting may belong to another tool or a newer
+// glint, so they are skipped and returned as warnings; invalid values of
+// settings are errors.
+func (c *Config) ApplyEnv(environ []string) (warnings []string, err error) {
+	environ = append([]string(nil), environ...)
+	sort.Strings(environ)
+
+	var errs []error
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		rest, ok := strings.CutPrefix(name, EnvPrefix)
+		if !ok || rest == "" {
+			continue
+		}
+		key, err := envKey(rest, rule.GlobalRegistry())
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("env %s: %v; ignored", name, err))
+			continue
+		}
+		if err := c.Set(key, value, "env "+name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return warnings, errors.Join(errs...)
+}
+
+// toRaw returns cfg as a generic map, as config files are read.
+func toRaw(cfg *Config) (map[string]any, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("encoding config: %w", err)
+	}
+	raw := make(map[string]any)
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("encoding config: %w", err)
+	}
+	return raw, nil
+}
+
+// setting is a position in the config schema: a key of Config, possibly
+// nested, or of a rule's options.
+type setting struct {
+	path    string
+	typ     reflect.Type     // nil for a rule option
+	rule    rule.Rule        // for rules.<name> and below
+	options bool             // rules.<name>.options
+	opt     *rule.OptionSpec // rules.<name>.options.<opt>
+}
+
+// keys returns the keys below s.
+func (s setting) keys(registry *rule.Registry) []string {
+	var keys []string
+	switch {
+	case s.options:
+		for _, spec := range rule.Schema(s.rule) {
+			keys = append(keys, spec.Name)
+		}
+	case s.path == "rules":
+		keys = registry.Names()
+	case s.typ != nil && s.typ.Kind() == reflect.Struct:
+		fields := make(map[string]reflect.Type)
+		structFields(s.typ, fields)
+		for name := range fields {
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// child returns the setting at key below s.
+func (s setting) child(key string, registry *rule.Registry) (setting, bool) {
+	path := key
+	if s.path != "" {
+		path = s.path + "." + key
+	}
+	switch {
+	case s.options:
+		for _, spec := range rule.Schema(s.rule) {
+			if spec.Name == key {
+				return setting{path: path, rule: s.rule, opt: &spec}, true
+			}
+		}
+	case s.path == "rules":
+		if r, ok := registry.Get(key); ok {
+			return setting{path: path, typ: reflect.TypeOf(RuleConfig{}), rule: r}, true
+		}
+	case s.typ != nil && s.typ.Kind() == reflect.Struct:
+		fields := make(map[string]reflect.Type)
+		structFields(s.typ, fields)
+		if t, ok := fields[key]; ok {
+			if t.Kind() == reflect.Pointer {
+				t = t.Elem() // optional, such as rules.<name>.enabled
+			}
+			return setting{path: path, typ: t, rule: s.rule, options: s.rule != nil && key == "options"}, true
+		}
+	}
+	return setting{}, false
+}
+
+func (s setting) leaf() bool {
+	if s.opt != nil {
+		return true
+	}
+	if s.options || s.path == "rules" {
+		return false
+	}
+	return s.typ.Kind() != reflect.Struct
+}
+
+// lookup returns the setting at the dotted key path.
+func lookup(key string, registry *rule.Registry) (setting, error) {
+	s := setting{typ: reflect.TypeOf(Config{})}
+	for _, part := range strings.Split(key, ".") {
+		child, ok := s.child(part, registry)
+		if !ok {
+			return setting{}, unknownKey(s, part, registry)
+		}
+		s = child
+	}
+	if !s.leaf() {
+		return setting{}, fmt.Errorf("%s is a section; set one of its keys: %s", key, strings.Join(s.keys(registry), ", "))
+	}
+	return s, nil
+}
+
+func unknownKey(s setting, key string, registry *rule.Registry) error {
+	switch {
+	case s.path == "rules":
+		return fmt.Errorf("unknown rule %q%s", key, suggest(key, s.keys(registry)))
+	case s.options && len(s.keys(registry)) == 0:
+		return fmt.Errorf("rule %s does not accept options", s.rule.Name())
+	case s.options:
+		return fmt.Errorf("rule %s has no option %q%s", s.rule.Name(), key, suggest(key, s.keys(registry)))
+	case s.path == "":
+		return fmt.Errorf("unknown key %q%s", key, suggest(key, s.keys(registry)))
+	case s.typ != nil && s.typ.Kind() != reflect.Struct:
+		return fmt.Errorf("%s has no keys", s.path)
+	}
+	return fmt.Errorf("unknown key in %s: %q%s", s.path, key, suggest(key, s.keys(registry)))
+}
+
+// envKey returns the key path that the environment variable name, without
+// its prefix, refers to. Where a name could be split several ways, the
+// longest key at each level wins.
+func envKey(name string, registry *rule.Registry) (string, error) {
+	s := setting{typ: reflect.TypeOf(Config{})}
+	rest := name
+	for {
+		best := ""
+		var names []string
+		for _, k := range s.keys(registry) {
+			e := envName(k)
+			if (rest == e || strings.HasPrefix(rest, e+"_")) && len(k) > len(best) {
+				best = k
+			}
+			names = append(names, e)
+		}
+		if best == "" {
+			if c := closestEnv(rest, names); c != "" {
+				return "", fmt.Errorf("no such setting (did you mean %q?)", EnvPrefix+name[:len(name)-len(rest)]+c)
+			}
+			return "", errors.New("no such setting")
+		}
+		s, _ = s.child(best, registry)
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, envName(best)), "_")
+		if rest == "" {
+			return s.path, nil
+		}
+	}
+}
+
+// closestEnv returns rest with its leading words replaced by the name in
+// names they are a few edits away from, or "". Only those words are
+// compared: the shared prefix and the settings below would make every
+// name look close.
+func closestEnv(rest string, names []string) string {
+	words := strings.Split(rest, "_")
+	best, bestDist := "", 4
+	for _, e := range names {
+		n := min(strings.Count(e, "_")+1, len(words))
+		head := strings.Join(words[:n], "_")
+		limit := min(len(head)/3+1, 3)
+		if d := levenshtein(head, e); d <= limit && d < bestDist {
+			best, bestDist = e+strings.TrimPrefix(rest, head), d
+		}
+	}
+	return best
+}
+
+func envName(key string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+// coerce converts the string form of a value to the setting's type.
+func (s setting) coerce(value string) (any, error) {
+	if s.opt != nil {
+		switch s.opt.Type {
+		case rule.OptionInt:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("want an integer, got %q", value)
+			}
+			return n, nil
+		case rule.OptionBool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("want true or false, got %q", value)
+			}
+			return b, nil
+		case rule.OptionStringList:
+			return splitList(value), nil
+		}
+		return value, nil
+	}
+
+	switch {
+	case s.typ == reflect.TypeOf(time.Duration(0)):
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, fmt.Errorf("want %s, got %q", typeName(s.typ), value)
+		}
+		return value, nil
+	case s.typ.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("want %s, got %q", typeName(s.typ), value)
+		}
+		return b, nil
+	case s.typ.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("want %s, got %q", typeName(s.typ), value)
+		}
+		return n, nil
+	case s.typ.Kind() == reflect.String:
+		return value, nil
+	case s.typ.Kind() == reflect.Slice && s.typ.Elem().Kind() == reflect.String:
+		return splitList(value), nil
+	}
+	return nil, fmt.Errorf("cannot be set from a string; use a config file")
+}
+
+// check validates v, the coerced value of s, as the value of a config
+// file would be.
+func (s setting) check(v any, source string) error {
+	if s.opt != nil {
+		return nil
+	}
+	if s.rule != nil && strings.HasSuffix(s.path, ".severity") {
+		if _, err := rule.ParseSeverity(v.(string)); err != nil {
+			return fmt.Errorf("%s: %s: %w", source, s.path, err)
+		}
+		return nil
+	}
+	var n yaml.Node
+	if err := n.Encode(v); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	val := &validator{file: source}
+	val.value(&n, s.typ, s.path)
+	if len(val.errs) == 0 {
+		return nil
+	}
+	for _, e := range val.errs {
+		e.Line, e.Column = 0, 0
+	}
+	return val.errs
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
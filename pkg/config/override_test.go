@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/nicholas/glint/pkg/rules/bugs"
+	_ "github.com/nicholas/glint/pkg/rules/style"
+)
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, ".glint.yml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func enabled(rc RuleConfig) string {
+	if rc.Enabled == nil {
+		return "unset"
+	}
+	if *rc.Enabled {
+		return "on"
+	}
+	return "off"
+}
+
+func TestRuleWithoutEnabled(t *testing.T) {
+	root := t.TempDir()
+	path := writeConfig(t, root, `enable_all: true
+rules:
+  shadow-var: {severity: info}
+  nil-deref:
+  unchecked-error: {enabled: true}
+`)
+	writeConfig(t, filepath.Join(root, "sub"), `rules:
+  unchecked-error: {severity: info}
+  import-order: {severity: info}
+`)
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Settings changed by overrides keep the rule's selection.
+	for _, kv := range [][2]string{
+		{"rules.line-length.severity", "info"},
+		{"rules.shadow-var.severity", "warning"},
+	} {
+		if err := cfg.Set(kv[0], kv[1], "flag --set"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		rule, want string
+	}{
+		{"shadow-var", "off"},
+		{"nil-deref", "off"},
+		{"unchecked-error", "on"},
+		{"line-length", "unset"},
+	}
+	for _, tt := range tests {
+		if got := enabled(cfg.Rules[tt.rule]); got != tt.want {
+			t.Errorf("%s is %s, want %s", tt.rule, got, tt.want)
+		}
+	}
+
+	sub, err := NewTree(root, cfg).ForDir(filepath.Join(root, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests = []struct {
+		rule, want string
+	}{
+		{"unchecked-error", "on"}, // as the root file has it
+		{"import-order", "off"},
+		{"shadow-var", "off"},
+		{"line-length", "unset"},
+	}
+	for _, tt := range tests {
+		if got := enabled(sub.Rules[tt.rule]); got != tt.want {
+			t.Errorf("sub: %s is %s, want %s", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestApplyEnvUnknown(t *testing.T) {
+	tests := []struct {
+		env, want string
+	}{
+		{"GLINT_FOO=1", "env GLINT_FOO: no such setting; ignored"},
+		{"GLINT_CONCURENCY=4", `env GLINT_CONCURENCY: no such setting (did you mean "GLINT_CONCURRENCY"?); ignored`},
+		{"GLINT_CACHE_DIRR=x", `env GLINT_CACHE_DIRR: no such setting (did you mean "GLINT_CACHE_DIR"?); ignored`},
+		{"GLINT_CACHE_FOO=x", "env GLINT_CACHE_FOO: no such setting; ignored"},
+		{"GLINT_RULES_LINE_LENGHT_SEVERITY=info", `env GLINT_RULES_LINE_LENGHT_SEVERITY: no such setting (did you mean "GLINT_RULES_LINE_LENGTH_SEVERITY"?); ignored`},
+	}
+	for _, tt := range tests {
+		warnings, err := DefaultConfig().ApplyEnv([]string{tt.env})
+		if err != nil {
+			t.Errorf("%s: %v", tt.env, err)
+		}
+		if len(warnings) != 1 || warnings[0] != tt.want {
+			t.Errorf("%s: warnings %q, want %q", tt.env, warnings, tt.want)
+		}
+	}
+}
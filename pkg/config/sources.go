@@ -0,0 +1,56 @@
+package config
+
+import "strings"
+
+// Sources records where the settings of a Config came from, by dotted key
+// path such as "cache.max_size" or "rules.line-length.options.max". The
+// source is a config file path or a description such as "flag --format";
+// settings without an entry come from the defaults.
+type Sources map[string]string
+
+// Set records src as the source of the setting at path, and of everything
+// below it.
+func (s Sources) Set(path, src string) {
+	if s == nil {
+		return
+	}
+	for p := range s {
+		if strings.HasPrefix(p, path+".") {
+			delete(s, p)
+		}
+	}
+	s[path] = src
+}
+
+// Of returns the source of the setting at path, or "default".
+func (s Sources) Of(path string) string {
+	for p := path; ; {
+		if src, ok := s[p]; ok {
+			return src
+		}
+		i := strings.LastIndexByte(p, '.')
+		if i < 0 {
+			return "default"
+		}
+		p = p[:i]
+	}
+}
+
+// record sets src as the source of every value in raw, a config file's
+// contents, below the key path prefix.
+func (s Sources) record(raw map[string]any, src, prefix string) {
+	if s == nil {
+		return
+	}
+	for k, v := range raw {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			s.record(m, src, path)
+			continue
+		}
+		s.Set(path, src)
+	}
+}
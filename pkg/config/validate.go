@@ -0,0 +1,379 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/nicholas/glint/pkg/glob"
+	"github.com/nicholas/glint/pkg/rule"
+	"gopkg.in/yaml.v3"
+)
+
+// Error is a problem at a position in a config file, or in an override
+// when Line is 0.
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// ErrorList holds every problem found in a config file.
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// enums lists the values accepted by string settings, by key path.
+var enums = map[string][]string{
+	"cache.backend":         {"pack", "dir"},
+	"cache.remote.layout":   {"bazel", "gradle"},
+	"output.format":         {"text", "json", "sarif"},
+	"output.fail_on":        {"error", "warning", "info", "none"},
+	"output.outputs.format": {"text", "json", "sarif"},
+	"presets":               rule.PresetNames(),
+	"enable_categories":     categories,
+	"disable_categories":    categories,
+}
+
+var categories = []string{"bugs", "style", "perf", "security"}
+
+// validator checks a config file against the Config schema and the
+// registered rules.
+type validator struct {
+	file     string
+	registry *rule.Registry
+	errs     ErrorList
+}
+
+// validate checks the config file contents data, reporting unknown keys,
+// rule names and option keys, invalid values and values of the wrong
+// type. Rule names are only checked when registry has rules.
+func validate(file string, data []byte, registry *rule.Registry) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing config %s: %w", file, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	v := &validator{file: file, registry: registry}
+	root := doc.Content[0]
+	if root.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "extends" {
+				v.extends(root.Content[i+1])
+			}
+		}
+	}
+	v.value(root, reflect.TypeOf(Config{}), "")
+	if len(v.errs) > 0 {
+		sort.SliceStable(v.errs, func(i, j int) bool {
+			a, b := v.errs[i], v.errs[j]
+			return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+		})
+		return v.errs
+	}
+	return nil
+}
+
+func (v *validator) errorf(n *yaml.Node, format string, args ...any) {
+	v.errs = append(v.errs, &Error{File: v.file, Line: n.Line, Column: n.Column, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) extends(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return
+	case yaml.SequenceNode:
+		for _, e := range n.Content {
+			if e.Kind != yaml.ScalarNode {
+				v.errorf(e, "extends: want a path")
+			}
+		}
+	default:
+		v.errorf(n, "extends: want a path or a list of paths")
+	}
+}
+
+// value checks n against Go type t; path is the dotted key path of n.
+func (v *validator) value(n *yaml.Node, t reflect.Type, path string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		v.mapping(n, t, path)
+		return
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			v.errorf(n, "%s: want a list", path)
+			return
+		}
+		for _, e := range n.Content {
+			v.value(e, t.Elem(), path)
+		}
+		if path == "output.outputs" {
+			v.outputs(n)
+		}
+		if path == "include" || path == "exclude" || strings.HasSuffix(path, ".include") || strings.HasSuffix(path, ".exclude") {
+			for _, e := range n.Content {
+				if err := glob.Validate(e.Value); err != nil {
+					v.errorf(e, "%s: %v", path, err)
+				}
+			}
+		}
+		return
+	case reflect.Map:
+		if path == "rules" {
+			v.rules(n)
+			return
+		}
+	}
+
+	if err := n.Decode(reflect.New(t).Interface()); err != nil {
+		v.errorf(n, "%s: want %s, got %q", path, typeName(t), n.Value)
+		return
+	}
+	if allowed, ok := enums[path]; ok && n.Value != "" && !slices.Contains(allowed, n.Value) {
+		v.errorf(n, "%s: unknown value %q%s (valid: %s)", path, n.Value, suggest(n.Value, allowed), strings.Join(allowed, ", "))
+	}
+	if path == "output.max_issues" && strings.HasPrefix(n.Value, "-") {
+		v.errorf(n, "%s: want a count of at least 0, got %s", path, n.Value)
+	}
+	if path == "cache.max_size" {
+		if _, err := ParseSize(n.Value); err != nil {
+			v.errorf(n, "%s: %v", path, err)
+		}
+	}
+}
+
+// outputs checks that at most one of the outputs in n goes to standard
+// output.
+func (v *validator) outputs(n *yaml.Node) {
+	stdout := 0
+	for _, e := range n.Content {
+		var t OutputTarget
+		if e.Decode(&t) == nil && t.Path == "" {
+			if stdout++; stdout > 1 {
+				v.errorf(e, "output.outputs: at most one output can go to standard output; give this one a path")
+			}
+		}
+	}
+}
+
+// mapping checks n against the fields of struct type t.
+func (v *validator) mapping(n *yaml.Node, t reflect.Type, path string) {
+	if n.Kind != yaml.MappingNode {
+		name := path
+		if name == "" {
+			name = "config"
+		}
+		v.errorf(n, "%s: want a mapping", name)
+		return
+	}
+	fields := make(map[string]reflect.Type)
+	structFields(t, fields)
+	if path == "" {
+		fields["extends"] = nil // checked by validate
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		ft, ok := fields[key.Value]
+		if !ok {
+			where := "unknown key"
+			if path != "" {
+				where = "unknown key in " + path + ":"
+			}
+			v.errorf(key, "%s %q%s", where, key.Value, suggest(key.Value, names))
+			continue
+		}
+		if ft == nil {
+			continue
+		}
+		sub := key.Value
+		if path != "" {
+			sub = path + "." + key.Value
+		}
+		v.value(val, ft, sub)
+	}
+}
+
+// structFields collects the YAML keys of struct type t and their types,
+// including those of inlined fields.
+func structFields(t reflect.Type, out map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("yaml")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			structFields(f.Type, out)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		out[name] = f.Type
+	}
+}
+
+// rules checks the rules section: rule names against the registry, and
+// each rule's severity and options.
+func (v *validator) rules(n *yaml.Node) {
+	if n.Kind != yaml.MappingNode {
+		v.errorf(n, "rules: want a mapping")
+		return
+	}
+	var names []string
+	if v.registry != nil {
+		names = v.registry.Names()
+		sort.Strings(names)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		path := "rules." + key.Value
+
+		var r rule.Rule
+		if len(names) > 0 {
+			var ok bool
+			if r, ok = v.registry.Get(key.Value); !ok {
+				v.errorf(key, "unknown rule %q%s", key.Value, suggest(key.Value, names))
+				continue
+			}
+		}
+		v.value(val, reflect.TypeOf(RuleConfig{}), path)
+		if val.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(val.Content); j += 2 {
+			k, field := val.Content[j], val.Content[j+1]
+			switch k.Value {
+			case "severity":
+				if _, err := rule.ParseSeverity(field.Value); err != nil && field.Tag != "!!null" {
+					v.errorf(field, "%s.severity: unknown severity %q%s (valid: info, warning, error)",
+						path, field.Value, suggest(field.Value, []string{"info", "warning", "error"}))
+				}
+			case "options":
+				if r != nil {
+					v.options(field, r, path+".options")
+				}
+			}
+		}
+	}
+}
+
+// options checks a rule's options against its schema.
+func (v *validator) options(n *yaml.Node, r rule.Rule, path string) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	schema := rule.Schema(r)
+	specs := make(map[string]rule.OptionSpec, len(schema))
+	names := make([]string, 0, len(schema))
+	for _, spec := range schema {
+		specs[spec.Name] = spec
+		names = append(names, spec.Name)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		spec, ok := specs[key.Value]
+		if !ok {
+			if len(specs) == 0 {
+				v.errorf(key, "rule %s does not accept options (got %q)", r.Name(), key.Value)
+			} else {
+				v.errorf(key, "rule %s has no option %q%s (valid: %s)", r.Name(), key.Value, suggest(key.Value, names), strings.Join(names, ", "))
+			}
+			continue
+		}
+		var raw any
+		if err := val.Decode(&raw); err != nil {
+			v.errorf(val, "%s.%s: %v", path, key.Value, err)
+			continue
+		}
+		if _, err := rule.CoerceOption(spec.Type, raw); err != nil {
+			v.errorf(val, "%s.%s: %v", path, key.Value, err)
+		}
+	}
+}
+
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t.String() == "time.Duration":
+		return "a duration such as 30m or 720h"
+	case t.Kind() == reflect.Bool:
+		return "true or false"
+	case t.Kind() == reflect.Int, t.Kind() == reflect.Int64:
+		return "an integer"
+	case t.Kind() == reflect.String:
+		return "a string"
+	}
+	return t.String()
+}
+
+// suggest returns a " (did you mean ...?)" hint naming the candidate
+// closest to s, if any is close enough to be a likely typo.
+func suggest(s string, candidates []string) string {
+	best, bestDist := "", len(s)/3+1
+	if bestDist > 3 {
+		bestDist = 3
+	}
+	for _, c := range candidates {
+		if d := levenshtein(s, c); d < bestDist || d == bestDist && best == "" {
+			best, bestDist = c, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
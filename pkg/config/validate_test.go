@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{
+			name: "valid",
+			yaml: `enable_all: true
+rules:
+  line-length: {enabled: true, severity: info, options: {max: 100}}
+output:
+  fail_on: warning
+`,
+		},
+		{
+			name: "unknown key",
+			yaml: "enable_al: true\ncache:\n  dirr: /tmp\n  zzzzzz: 1\n",
+			want: []string{
+				`.glint.yml:1:1: unknown key "enable_al" (did you mean "enable_all"?)`,
+				`.glint.yml:3:3: unknown key in cache: "dirr" (did you mean "dir"?)`,
+				`.glint.yml:4:3: unknown key in cache: "zzzzzz"`,
+			},
+		},
+		{
+			name: "unknown rule",
+			yaml: "rules:\n  line-lenght: {enabled: true}\n  no-such-thing-at-all: {enabled: true}\n",
+			want: []string{
+				`.glint.yml:2:3: unknown rule "line-lenght" (did you mean "line-length"?)`,
+				`.glint.yml:3:3: unknown rule "no-such-thing-at-all"`,
+			},
+		},
+		{
+			name: "bad severity",
+			yaml: "rules:\n  line-length:\n    severity: eror\n  shadow-var: {severity: loud}\n",
+			want: []string{
+				`.glint.yml:3:15: rules.line-length.severity: unknown severity "eror" (did you mean "error"?) (valid: info, warning, error)`,
+				`.glint.yml:4:26: rules.shadow-var.severity: unknown severity "loud" (valid: info, warning, error)`,
+			},
+		},
+		{
+			name: "wrong type",
+			yaml: "concurrency: lots\nenable_all: maybe\nexclude: vendor/\nrules:\n  line-length:\n    enabled: yes please\n",
+			want: []string{
+				`.glint.yml:1:14: concurrency: want an integer, got "lots"`,
+				`.glint.yml:2:13: enable_all: want true or false, got "maybe"`,
+				`.glint.yml:3:10: exclude: want a list`,
+				`.glint.yml:6:14: rules.line-length.enabled: want true or false, got "yes please"`,
+			},
+		},
+		{
+			name: "bad enum",
+			yaml: "output:\n  format: jsn\n",
+			want: []string{
+				`.glint.yml:2:11: output.format: unknown value "jsn" (did you mean "json"?) (valid: text, json, sarif)`,
+			},
+		},
+		{
+			name: "rule options",
+			yaml: "rules:\n  line-length:\n    options: {maxx: 100}\n  shadow-var:\n    options: {max: 1}\n",
+			want: []string{
+				`.glint.yml:3:15: rule line-length has no option "maxx" (did you mean "max"?) (valid: max)`,
+				`.glint.yml:5:15: rule shadow-var does not accept options (got "max")`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(".glint.yml", []byte(tt.yaml), rule.GlobalRegistry())
+			var got []string
+			if err != nil {
+				var list ErrorList
+				if !errors.As(err, &list) {
+					t.Fatalf("validate returned %T %v, want an ErrorList", err, err)
+				}
+				for _, e := range list {
+					got = append(got, e.Error())
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateFileExtends(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfig(t, filepath.Join(dir, "base"), "rules:\n  shadow-var: {severity: loud}\n")
+	path := writeConfig(t, dir, "extends: base/.glint.yml\nconcurrency: 2\n")
+
+	err := ValidateFile(path)
+	want := base + `:2:26: rules.shadow-var.severity: unknown severity "loud" (valid: info, warning, error)`
+	if err == nil || err.Error() != want {
+		t.Errorf("ValidateFile = %v, want %s", err, want)
+	}
+}
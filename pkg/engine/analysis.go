@@ -0,0 +1,291 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// analysisDriver runs go/analysis analyzers over loaded packages the way
+// the go/analysis checker does: an analyzer's Requires run first on the
+// same package, and analyzers with facts run on every dependency first so
+// that facts about imported objects are available.
+type analysisDriver struct {
+	rules []rule.AnalyzerRule
+}
+
+func newAnalysisDriver(rules []rule.AnalyzerRule) *analysisDriver {
+	if len(rules) == 0 {
+		return nil
+	}
+	return &analysisDriver{rules: rules}
+}
+
+type actionKey struct {
+	a   *analysis.Analyzer
+	pkg *packages.Package
+}
+
+// action is one analyzer applied to one package.
+type action struct {
+	once sync.Once
+	a    *analysis.Analyzer
+	pkg  *packages.Package
+
+	// reqs are the analyzers a requires, on the same package; imports
+	// are a itself on the package's imports, for facts.
+	reqs    []*action
+	imports []*action
+
+	result any
+	diags  []analysis.Diagnostic
+	err    error
+}
+
+// factKey identifies a fact of one type about an object or, when obj is
+// nil, about a package.
+type factKey struct {
+	a   *analysis.Analyzer
+	pkg *types.Package
+	obj types.Object
+	t   reflect.Type
+}
+
+type factStore struct {
+	mu    sync.Mutex
+	facts map[factKey]analysis.Fact
+}
+
+// run analyzes roots and returns the diagnostics of each, keyed by
+// package. Analyzer failures on dependencies only cost their facts;
+// failures on a root are returned as errors.
+func (d *analysisDriver) run(ctx context.Context, roots []*packages.Package, concurrency int) (map[*packages.Package][]rule.Diagnostic, error) {
+	actions := make(map[actionKey]*action)
+	var mk func(a *analysis.Analyzer, pkg *packages.Package) *action
+	mk = func(a *analysis.Analyzer, pkg *packages.Package) *action {
+		key := actionKey{a, pkg}
+		if act, ok := actions[key]; ok {
+			return act
+		}
+		act := &action{a: a, pkg: pkg}
+		actions[key] = act
+		for _, req := range a.Requires {
+			act.reqs = append(act.reqs, mk(req, pkg))
+		}
+		if len(a.FactTypes) > 0 {
+			for _, imp := range pkg.Imports {
+				act.imports = append(act.imports, mk(a, imp))
+			}
+		}
+		return act
+	}
+
+	type rootAction struct {
+		act *action
+		r   rule.AnalyzerRule
+	}
+	var todo []rootAction
+	for _, pkg := range roots {
+		for _, r := range d.rules {
+			todo = append(todo, rootAction{mk(r.Analyzer(), pkg), r})
+		}
+	}
+
+	facts := &factStore{facts: make(map[factKey]analysis.Fact)}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, ra := range todo {
+		g.Go(func() error {
+			if gctx.Err() != nil {
+				return gctx.Err()
+			}
+			ra.act.exec(facts)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	out := make(map[*packages.Package][]rule.Diagnostic, len(roots))
+	for _, ra := range todo {
+		act := ra.act
+		if act.err != nil {
+			return nil, fmt.Errorf("analyzer %s on %s: %w", act.a.Name, act.pkg.PkgPath, act.err)
+		}
+		for _, ad := range act.diags {
+			out[act.pkg] = append(out[act.pkg], toDiagnostic(act.pkg.Fset, ra.r, ad))
+		}
+	}
+	return out, nil
+}
+
+func (act *action) exec(facts *factStore) {
+	act.once.Do(func() {
+		for _, dep := range act.reqs {
+			dep.exec(facts)
+		}
+		for _, dep := range act.imports {
+			dep.exec(facts)
+		}
+		act.err = act.analyze(facts)
+	})
+}
+
+func (act *action) analyze(facts *factStore) error {
+	pkg := act.pkg
+	if pkg.Types == nil || pkg.TypesInfo == nil {
+		return fmt.Errorf("no type information")
+	}
+	if pkg.IllTyped && !act.a.RunDespiteErrors {
+		return fmt.Errorf("package has errors")
+	}
+
+	results := make(map[*analysis.Analyzer]any, len(act.reqs))
+	for _, dep := range act.reqs {
+		if dep.err != nil {
+			return fmt.Errorf("required analyzer %s failed: %w", dep.a.Name, dep.err)
+		}
+		results[dep.a] = dep.result
+	}
+
+	var typeErrors []types.Error
+	for _, e := range pkg.TypeErrors {
+		typeErrors = append(typeErrors, e)
+	}
+
+	pass := &analysis.Pass{
+		Analyzer:     act.a,
+		Fset:         pkg.Fset,
+		Files:        pkg.Syntax,
+		OtherFiles:   pkg.OtherFiles,
+		IgnoredFiles: pkg.IgnoredFiles,
+		Pkg:          pkg.Types,
+		TypesInfo:    pkg.TypesInfo,
+		TypesSizes:   pkg.TypesSizes,
+		TypeErrors:   typeErrors,
+		ResultOf:     results,
+		ReadFile:     os.ReadFile,
+		Report:       func(d analysis.Diagnostic) { act.diags = append(act.diags, d) },
+
+		ImportObjectFact: func(obj types.Object, fact analysis.Fact) bool {
+			if obj == nil {
+				return false
+			}
+			return facts.get(factKey{act.a, nil, obj, reflect.TypeOf(fact)}, fact)
+		},
+		ImportPackageFact: func(p *types.Package, fact analysis.Fact) bool {
+			return facts.get(factKey{act.a, p, nil, reflect.TypeOf(fact)}, fact)
+		},
+		ExportObjectFact: func(obj types.Object, fact analysis.Fact) {
+			facts.set(factKey{act.a, nil, obj, reflect.TypeOf(fact)}, fact)
+		},
+		ExportPackageFact: func(fact analysis.Fact) {
+			facts.set(factKey{act.a, pkg.Types, nil, reflect.TypeOf(fact)}, fact)
+		},
+		AllObjectFacts: func() []analysis.ObjectFact {
+			return facts.objectFacts(act.a)
+		},
+		AllPackageFacts: func() []analysis.PackageFact {
+			return facts.packageFacts(act.a)
+		},
+	}
+	if m := pkg.Module; m != nil {
+		pass.Module = &analysis.Module{Path: m.Path, Version: m.Version, GoVersion: m.GoVersion}
+	}
+
+	result, err := act.a.Run(pass)
+	if err != nil {
+		return err
+	}
+	act.result = result
+	return nil
+}
+
+// get copies the stored fact for key into fact, which must be a pointer
+// of the same type.
+func (s *factStore) get(key factKey, fact analysis.Fact) bool {
+	s.mu.Lock()
+	stored, ok := s.facts[key]
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+	reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(stored).Elem())
+	return true
+}
+
+func (s *factStore) set(key factKey, fact analysis.Fact) {
+	s.mu.Lock()
+	s.facts[key] = fact
+	s.mu.Unlock()
+}
+
+func (s *factStore) objectFacts(a *analysis.Analyzer) []analysis.ObjectFact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []analysis.ObjectFact
+	for k, f := range s.facts {
+		if k.a == a && k.obj != nil {
+			out = append(out, analysis.ObjectFact{Object: k.obj, Fact: f})
+		}
+	}
+	return out
+}
+
+func (s *factStore) packageFacts(a *analysis.Analyzer) []analysis.PackageFact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []analysis.PackageFact
+	for k, f := range s.facts {
+		if k.a == a && k.pkg != nil {
+			out = append(out, analysis.PackageFact{Package: k.pkg, Fact: f})
+		}
+	}
+	return out
+}
+
+// toDiagnostic converts an analyzer diagnostic into a glint one reported
+// under r's name. Fix edits become byte offsets, like those built by
+// rule.Context.Edit.
+func toDiagnostic(fset *token.FileSet, r rule.AnalyzerRule, ad analysis.Diagnostic) rule.Diagnostic {
+	d := rule.Diagnostic{
+		Rule:     r.Name(),
+		Category: r.Category(),
+		Severity: r.Severity(),
+		Pos:      fset.Position(ad.Pos),
+		Message:  ad.Message,
+	}
+	if ad.End.IsValid() {
+		d.End = fset.Position(ad.End)
+	} else {
+		d.End = d.Pos
+	}
+	for _, af := range ad.SuggestedFixes {
+		fix := rule.SuggestedFix{Message: af.Message}
+		for _, te := range af.TextEdits {
+			start := fset.Position(te.Pos)
+			end := start
+			if te.End.IsValid() {
+				end = fset.Position(te.End)
+			}
+			fix.Edits = append(fix.Edits, rule.TextEdit{
+				Filename: start.Filename,
+				Start:    start.Offset,
+				End:      end.Offset,
+				NewText:  string(te.NewText),
+			})
+		}
+		d.SuggestedFixes = append(d.SuggestedFixes, fix)
+	}
+	return d
+}
@@ -1,21 +1,55 @@
 package engine
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/gob"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/nicholas/glint/pkg/config"
+	"github.com/nicholas/glint/pkg/fsutil"
 	"github.com/nicholas/glint/pkg/rule"
 )
 
+// cacheFormat is bumped whenever the encoding of cached diagnostics
+// changes, so entries written by older versions are ignored.
+const cacheFormat = "3"
+
+// CacheBackend stores encoded cache entries by key. Implementations must
+// be safe for concurrent use.
+type CacheBackend interface {
+	Get(key string) ([]byte, bool)
+	Put(key string, data []byte)
+	// Flush persists buffered writes; it is called at the end of a run.
+	Flush() error
+	Clear() error
+	// Usage returns the number of entries and their total size.
+	Usage() (entries int, bytes int64, err error)
+	// Prune evicts entries unused for longer than maxAge, then the least
+	// recently used ones until the rest fit in maxBytes. Zero limits are
+	// ignored. It returns the number of entries removed.
+	Prune(maxBytes int64, maxAge time.Duration) (int, error)
+}
+
+// Cache backend names, as used in the cache.backend config key.
+const (
+	BackendPack = "pack"
+	BackendDir  = "dir"
+)
+
 type Cache struct {
-	mu      sync.RWMutex
 	dir     string
 	enabled bool
+	backend CacheBackend
+	root    string
+
+	hits, misses atomic.Int64
 }
 
 type cachedResult struct {
@@ -23,13 +57,32 @@ type cachedResult struct {
 	Diagnostics []rule.Diagnostic
 }
 
-func NewCache(dir string, enabled bool) (*Cache, error) {
-	if enabled && dir != "" {
+// NewCache opens the cache described by cfg: the packed or directory
+// backend, optionally behind a remote cache server. Paths in keys and
+// entries are stored relative to the working directory, so that entries
+// can be shared between checkouts in different places.
+func NewCache(cfg config.CacheConfig) (*Cache, error) {
+	dir := cfg.Dir
+	if cfg.Enabled && dir != "" {
 		if err := os.MkdirAll(dir, 0o755); err != nil {
 			return nil, fmt.Errorf("creating cache dir: %w", err)
 		}
 	}
-	return &Cache{dir: dir, enabled: enabled}, nil
+
+	root, _ := os.Getwd()
+	c := &Cache{dir: dir, enabled: cfg.Enabled, root: root}
+	switch cfg.Backend {
+	case "", BackendPack:
+		c.backend = newPackBackend(dir)
+	case BackendDir:
+		c.backend = newDirBackend(dir)
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Backend)
+	}
+	if cfg.Remote.URL != "" {
+		c.backend = newRemoteBackend(c.backend, cfg.Remote)
+	}
+	return c, nil
 }
 
 func HashFile(data []byte) string {
@@ -37,8 +90,13 @@ func HashFile(data []byte) string {
 	return hex.EncodeToString(h[:])
 }
 
-func (c *Cache) cacheKey(filePath, ruleSet string) string {
-	h := sha256.Sum256([]byte(filePath + "\x00" + ruleSet))
+// cacheKey addresses an entry by everything its result depends on:
+// fileHash covers the file's contents and, for type-aware rule sets, its
+// package's dependencies. Machines and branches that see the same inputs
+// share the entry, and a changed file gets a new one, leaving the old one
+// to eviction.
+func (c *Cache) cacheKey(filePath, fileHash, ruleSet string) string {
+	h := sha256.Sum256([]byte(cacheFormat + "\x00" + c.rel(filePath) + "\x00" + fileHash + "\x00" + ruleSet))
 	return hex.EncodeToString(h[:16])
 }
 
@@ -46,29 +104,31 @@ func (c *Cache) Lookup(filePath, fileHash, ruleSet string) ([]rule.Diagnostic, b
 	if !c.enabled {
 		return nil, false
 	}
+	diags, ok := c.lookup(filePath, fileHash, ruleSet)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return diags, ok
+}
 
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	key := c.cacheKey(filePath, ruleSet)
-	path := filepath.Join(c.dir, key+".gob")
-
-	f, err := os.Open(path)
-	if err != nil {
+func (c *Cache) lookup(filePath, fileHash, ruleSet string) ([]rule.Diagnostic, bool) {
+	data, ok := c.backend.Get(c.cacheKey(filePath, fileHash, ruleSet))
+	if !ok {
 		return nil, false
 	}
-	defer f.Close()
 
 	var cr cachedResult
-	decErr := gob.NewDecoder(f).Decode(&cr)
-	if decErr != nil {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&cr); err != nil {
 		return nil, false
 	}
-
 	if cr.FileHash != fileHash {
 		return nil, false
 	}
-
+	for i := range cr.Diagnostics {
+		mapFilenames(&cr.Diagnostics[i], c.abs)
+	}
 	return cr.Diagnostics, true
 }
 
@@ -77,36 +137,159 @@ func (c *Cache) Store(filePath, fileHash, ruleSet string, diags []rule.Diagnosti
 		return
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	key := c.cacheKey(filePath, ruleSet)
-	path := filepath.Join(c.dir, key+".gob")
-
-	f, err := os.Create(path)
-	if err != nil {
-		return
+	rel := make([]rule.Diagnostic, len(diags))
+	for i, d := range diags {
+		d.SuggestedFixes = cloneFixes(d.SuggestedFixes)
+		mapFilenames(&d, c.rel)
+		rel[i] = d
 	}
-	defer f.Close()
 
-	_ = gob.NewEncoder(f).Encode(cachedResult{
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(cachedResult{
 		FileHash:    fileHash,
-		Diagnostics: diags,
+		Diagnostics: rel,
 	})
+	if err != nil {
+		return
+	}
+	c.backend.Put(c.cacheKey(filePath, fileHash, ruleSet), buf.Bytes())
 }
 
 func (c *Cache) Clear() error {
 	if c.dir == "" {
 		return nil
 	}
-	entries, err := os.ReadDir(c.dir)
-	if err != nil {
-		return err
+	// Clear the other layout as well, so that switching backends does
+	// not leave stale entries behind.
+	var other CacheBackend = newDirBackend(c.dir)
+	if _, isDir := c.backend.(*dirBackend); isDir {
+		other = newPackBackend(c.dir)
 	}
-	for _, e := range entries {
-		if filepath.Ext(e.Name()) == ".gob" {
-			_ = os.Remove(filepath.Join(c.dir, e.Name()))
+	for _, b := range []CacheBackend{c.backend, other} {
+		if err := b.Clear(); err != nil {
+			return err
 		}
 	}
+	if err := os.Remove(filepath.Join(c.dir, statsFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	return nil
 }
+
+// rel makes paths under the working directory relative to it.
+func (c *Cache) rel(path string) string {
+	if c.root == "" || !filepath.IsAbs(path) {
+		return path
+	}
+	if r, err := filepath.Rel(c.root, path); err == nil && filepath.IsLocal(r) {
+		return filepath.ToSlash(r)
+	}
+	return path
+}
+
+// abs undoes rel.
+func (c *Cache) abs(path string) string {
+	if c.root == "" || path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.root, filepath.FromSlash(path))
+}
+
+func mapFilenames(d *rule.Diagnostic, f func(string) string) {
+	d.Pos.Filename = f(d.Pos.Filename)
+	d.End.Filename = f(d.End.Filename)
+	for i := range d.SuggestedFixes {
+		for j := range d.SuggestedFixes[i].Edits {
+			e := &d.SuggestedFixes[i].Edits[j]
+			e.Filename = f(e.Filename)
+		}
+	}
+}
+
+func cloneFixes(fixes []rule.SuggestedFix) []rule.SuggestedFix {
+	if fixes == nil {
+		return nil
+	}
+	out := make([]rule.SuggestedFix, len(fixes))
+	for i, fix := range fixes {
+		out[i] = rule.SuggestedFix{
+			Message: fix.Message,
+			Edits:   append([]rule.TextEdit(nil), fix.Edits...),
+		}
+	}
+	return out
+}
+
+// Dir returns the cache directory.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// statsFile records the hit counts of the last run in the cache dir.
+const statsFile = "last-run.json"
+
+// RunStats describes cache usage during one run.
+type RunStats struct {
+	Time   time.Time `json:"time"`
+	Hits   int64     `json:"hits"`
+	Misses int64     `json:"misses"`
+}
+
+// HitRate returns the fraction of lookups served from the cache.
+func (s RunStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Status summarizes the cache directory.
+type Status struct {
+	Dir     string
+	Entries int
+	Bytes   int64
+	// LastRun is nil if no run has recorded statistics yet.
+	LastRun *RunStats
+}
+
+// Status reports the number and total size of the cache entries along
+// with the statistics of the last run.
+func (c *Cache) Status() (*Status, error) {
+	st := &Status{Dir: c.dir}
+	if c.dir == "" {
+		return st, nil
+	}
+	entries, size, err := c.backend.Usage()
+	if err != nil {
+		return nil, err
+	}
+	st.Entries, st.Bytes = entries, size
+
+	if data, err := os.ReadFile(filepath.Join(c.dir, statsFile)); err == nil {
+		var rs RunStats
+		if json.Unmarshal(data, &rs) == nil {
+			st.LastRun = &rs
+		}
+	}
+	return st, nil
+}
+
+// Finish records the statistics of the current run, applies the eviction
+// policy of CacheBackend.Prune and writes the cache back.
+func (c *Cache) Finish(maxBytes int64, maxAge time.Duration) error {
+	if !c.enabled || c.dir == "" {
+		return nil
+	}
+
+	rs := RunStats{Time: time.Now(), Hits: c.hits.Load(), Misses: c.misses.Load()}
+	if data, err := json.MarshalIndent(rs, "", "  "); err == nil {
+		_ = fsutil.WriteFileAtomic(filepath.Join(c.dir, statsFile), data, 0o644)
+	}
+
+	if maxBytes > 0 || maxAge > 0 {
+		if _, err := c.backend.Prune(maxBytes, maxAge); err != nil {
+			return err
+		}
+	}
+	return c.backend.Flush()
+}
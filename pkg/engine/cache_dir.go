@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nicholas/glint/pkg/fsutil"
+)
+
+// dirBackend keeps one .gob file per entry in the cache directory. Every
+// write is visible to other processes immediately, at the cost of one
+// file open per lookup.
+type dirBackend struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+func newDirBackend(dir string) *dirBackend {
+	return &dirBackend{dir: dir}
+}
+
+func (b *dirBackend) Get(key string) ([]byte, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	path := filepath.Join(b.dir, key+".gob")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	// The modification time doubles as the last-use time for eviction.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return data, true
+}
+
+func (b *dirBackend) Put(key string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Renaming a complete temp file into place keeps concurrent glint
+	// processes from reading or leaving behind a partial entry.
+	_ = fsutil.WriteFileAtomic(filepath.Join(b.dir, key+".gob"), data, 0o644)
+}
+
+func (b *dirBackend) Flush() error { return nil }
+
+func (b *dirBackend) Clear() error {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if filepath.Ext(name) == ".gob" || strings.HasSuffix(name, ".tmp") {
+			_ = os.Remove(filepath.Join(b.dir, name))
+		}
+	}
+	return nil
+}
+
+func (b *dirBackend) Usage() (int, int64, error) {
+	entries, err := b.entries()
+	if err != nil {
+		return 0, 0, err
+	}
+	var size int64
+	for _, e := range entries {
+		size += e.size
+	}
+	return len(entries), size, nil
+}
+
+func (b *dirBackend) Prune(maxBytes int64, maxAge time.Duration) (int, error) {
+	entries, err := b.entries()
+	if err != nil {
+		return 0, err
+	}
+	victims := evict(entries, maxBytes, maxAge)
+	removed := 0
+	for _, e := range victims {
+		if err := os.Remove(filepath.Join(b.dir, e.key+".gob")); err == nil || os.IsNotExist(err) {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (b *dirBackend) entries() ([]cacheEntry, error) {
+	dirEntries, err := os.ReadDir(b.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	out := make([]cacheEntry, 0, len(dirEntries))
+	for _, e := range dirEntries {
+		key, ok := strings.CutSuffix(e.Name(), ".gob")
+		if !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, cacheEntry{key: key, size: info.Size(), used: info.ModTime()})
+	}
+	return out, nil
+}
+
+type cacheEntry struct {
+	key  string
+	size int64
+	used time.Time
+}
+
+// evict picks the entries to remove: those unused since before maxAge,
+// then the least recently used until the rest fit in maxBytes.
+func evict(entries []cacheEntry, maxBytes int64, maxAge time.Duration) []cacheEntry {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].used.Before(entries[j].used) })
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+
+	var out []cacheEntry
+	cutoff := time.Now().Add(-maxAge)
+	for _, e := range entries {
+		expired := maxAge > 0 && e.used.Before(cutoff)
+		oversized := maxBytes > 0 && total > maxBytes
+		if !expired && !oversized {
+			continue
+		}
+		out = append(out, e)
+		total -= e.size
+	}
+	return out
+}
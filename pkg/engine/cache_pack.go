@@ -0,0 +1,254 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nicholas/glint/pkg/fsutil"
+)
+
+const (
+	// packFile holds the packed store inside the cache directory.
+	packFile  = "cache.pack"
+	packMagic = "GLINTPK1"
+
+	// packLockTimeout bounds the wait for another process to finish
+	// writing the pack.
+	packLockTimeout = 10 * time.Second
+
+	// touchInterval limits how often a hit refreshes an entry's last-use
+	// time, so that warm runs with nothing new don't rewrite the pack.
+	touchInterval = time.Hour
+)
+
+// packBackend keeps every entry in a single file. The file is read once,
+// on first use, and written back by Flush with a temp file and rename.
+// Flush holds a lock file while it merges in the entries other processes
+// stored in the meantime and writes the result, so concurrent runs only
+// lose each other's last-use times. Readers need no lock, since the pack
+// is replaced atomically.
+type packBackend struct {
+	path string
+	once sync.Once
+
+	mu      sync.RWMutex
+	entries map[string]*packEntry
+	removed map[string]bool
+	dirty   atomic.Bool
+}
+
+type packEntry struct {
+	data []byte
+	used atomic.Int64 // unix nanoseconds
+}
+
+func newPackBackend(dir string) *packBackend {
+	return &packBackend{
+		path:    filepath.Join(dir, packFile),
+		entries: make(map[string]*packEntry),
+		removed: make(map[string]bool),
+	}
+}
+
+func (b *packBackend) load() {
+	b.once.Do(func() {
+		entries, err := readPack(b.path)
+		if err != nil {
+			return // a missing or corrupt pack is an empty cache
+		}
+		b.mu.Lock()
+		b.entries = entries
+		b.mu.Unlock()
+	})
+}
+
+func (b *packBackend) Get(key string) ([]byte, bool) {
+	b.load()
+	b.mu.RLock()
+	e, ok := b.entries[key]
+	b.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	now := time.Now().UnixNano()
+	if now-e.used.Load() > int64(touchInterval) {
+		e.used.Store(now)
+		b.dirty.Store(true)
+	}
+	return e.data, true
+}
+
+func (b *packBackend) Put(key string, data []byte) {
+	b.load()
+	e := &packEntry{data: data}
+	e.used.Store(time.Now().UnixNano())
+
+	b.mu.Lock()
+	b.entries[key] = e
+	delete(b.removed, key)
+	b.mu.Unlock()
+	b.dirty.Store(true)
+}
+
+func (b *packBackend) Flush() error {
+	if !b.dirty.Load() {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	unlock, err := fsutil.Lock(b.path+".lock", packLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if onDisk, err := readPack(b.path); err == nil {
+		for key, e := range onDisk {
+			if _, ok := b.entries[key]; !ok && !b.removed[key] {
+				b.entries[key] = e
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(packMagic)
+	var tmp [binary.MaxVarintLen64]byte
+	for key, e := range b.entries {
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(key)))])
+		buf.WriteString(key)
+		buf.Write(tmp[:binary.PutVarint(tmp[:], e.used.Load())])
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], uint64(len(e.data)))])
+		buf.Write(e.data)
+	}
+	if err := fsutil.WriteFileAtomic(b.path, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	b.removed = make(map[string]bool)
+	b.dirty.Store(false)
+	return nil
+}
+
+func (b *packBackend) Clear() error {
+	b.load()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = make(map[string]*packEntry)
+	b.removed = make(map[string]bool)
+	b.dirty.Store(false)
+	unlock, err := fsutil.Lock(b.path+".lock", packLockTimeout)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil // no cache directory
+	}
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (b *packBackend) Usage() (int, int64, error) {
+	b.load()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var size int64
+	for key, e := range b.entries {
+		size += int64(len(key) + len(e.data))
+	}
+	return len(b.entries), size, nil
+}
+
+func (b *packBackend) Prune(maxBytes int64, maxAge time.Duration) (int, error) {
+	b.load()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := make([]cacheEntry, 0, len(b.entries))
+	for key, e := range b.entries {
+		entries = append(entries, cacheEntry{
+			key:  key,
+			size: int64(len(key) + len(e.data)),
+			used: time.Unix(0, e.used.Load()),
+		})
+	}
+	victims := evict(entries, maxBytes, maxAge)
+	for _, e := range victims {
+		delete(b.entries, e.key)
+		b.removed[e.key] = true
+	}
+	if len(victims) > 0 {
+		b.dirty.Store(true)
+	}
+	return len(victims), nil
+}
+
+var errBadPack = errors.New("malformed cache pack")
+
+// readPack decodes a pack file: the magic string followed by records of
+// key length, key, last-use time, data length and data.
+func readPack(path string) (map[string]*packEntry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(raw, []byte(packMagic)) {
+		return nil, errBadPack
+	}
+	r := bufio.NewReader(bytes.NewReader(raw[len(packMagic):]))
+
+	entries := make(map[string]*packEntry)
+	for {
+		keyLen, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, errBadPack
+		}
+		key, err := readN(r, keyLen)
+		if err != nil {
+			return nil, err
+		}
+		used, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, errBadPack
+		}
+		dataLen, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, errBadPack
+		}
+		data, err := readN(r, dataLen)
+		if err != nil {
+			return nil, err
+		}
+
+		e := &packEntry{data: data}
+		e.used.Store(used)
+		entries[string(key)] = e
+	}
+}
+
+func readN(r io.Reader, n uint64) ([]byte, error) {
+	if n > 1<<30 {
+		return nil, errBadPack
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, errBadPack
+	}
+	return buf, nil
+}
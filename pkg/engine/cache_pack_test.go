@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicholas/glint/pkg/fsutil"
+)
+
+func TestPackConcurrentFlush(t *testing.T) {
+	dir := t.TempDir()
+	const rounds = 20
+
+	for round := 0; round < rounds; round++ {
+		// Two processes that both loaded the pack before either flushed.
+		a, b := newPackBackend(dir), newPackBackend(dir)
+		a.load()
+		b.load()
+		a.Put(fmt.Sprintf("a%d", round), []byte("from a"))
+		b.Put(fmt.Sprintf("b%d", round), []byte("from b"))
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i, be := range []*packBackend{a, b} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = be.Flush()
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	got := newPackBackend(dir)
+	for round := 0; round < rounds; round++ {
+		for _, key := range []string{fmt.Sprintf("a%d", round), fmt.Sprintf("b%d", round)} {
+			if _, ok := got.Get(key); !ok {
+				t.Errorf("entry %s lost", key)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, packFile+".lock")); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+// TestPackFlushWaitsForLock checks that a flush waits while another
+// process writes the pack, and then merges that process's entries.
+func TestPackFlushWaitsForLock(t *testing.T) {
+	dir := t.TempDir()
+	b := newPackBackend(dir)
+	b.Put("b", []byte("from b"))
+
+	unlock, err := fsutil.Lock(filepath.Join(dir, packFile+".lock"), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- b.Flush() }()
+
+	// Meanwhile the lock holder writes its pack.
+	other := newPackBackend(t.TempDir())
+	other.Put("a", []byte("from a"))
+	if err := other.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(other.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("Flush returned %v while another process held the lock", err)
+	default:
+	}
+	if err := fsutil.WriteFileAtomic(filepath.Join(dir, packFile), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	got := newPackBackend(dir)
+	for _, key := range []string{"a", "b"} {
+		if _, ok := got.Get(key); !ok {
+			t.Errorf("entry %s lost", key)
+		}
+	}
+}
+
+func TestPackFlushKeepsRemovals(t *testing.T) {
+	dir := t.TempDir()
+	a := newPackBackend(dir)
+	a.Put("old", []byte("x"))
+	a.Put("kept", []byte("y"))
+	if err := a.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	b := newPackBackend(dir)
+	if n, err := b.Prune(1, 0); err != nil || n == 0 {
+		t.Fatalf("Prune = %d, %v; want entries removed", n, err)
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _, err := newPackBackend(dir).Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _, _ := b.Usage(); entries != want {
+		t.Errorf("pack holds %d entries after pruning, want %d", entries, want)
+	}
+}
+
+func TestPackClearWithoutDir(t *testing.T) {
+	b := newPackBackend(filepath.Join(t.TempDir(), "missing"))
+	if err := b.Clear(); err != nil {
+		t.Errorf("Clear of a missing cache: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nicholas/glint/pkg/config"
+)
+
+const (
+	// remoteMagic starts every remote entry, followed by the SHA-256 of
+	// the payload, so corrupt or foreign entries are detected.
+	remoteMagic        = "GLINTRC1"
+	remoteTimeout      = 10 * time.Second
+	remoteUploads      = 8
+	remoteMaxEntrySize = 64 << 20
+)
+
+// remoteBackend layers an HTTP cache server, as used for Bazel or Gradle
+// remote caching, over a local backend. Lookups try the local cache
+// first and keep what they fetch; new entries are uploaded by Flush. The
+// first server error disables the remote for the rest of the run, so a
+// missing server costs at most one timeout.
+type remoteBackend struct {
+	local    CacheBackend
+	client   *http.Client
+	base     string
+	prefix   string
+	readOnly bool
+
+	failed  atomic.Bool
+	mu      sync.Mutex
+	pending map[string][]byte
+}
+
+func newRemoteBackend(local CacheBackend, cfg config.RemoteCacheConfig) *remoteBackend {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = remoteTimeout
+	}
+	prefix := "/cache/"
+	if cfg.Layout == "bazel" {
+		prefix = "/ac/"
+	}
+	return &remoteBackend{
+		local:    local,
+		client:   &http.Client{Timeout: timeout},
+		base:     strings.TrimSuffix(cfg.URL, "/"),
+		prefix:   prefix,
+		readOnly: cfg.ReadOnly,
+		pending:  make(map[string][]byte),
+	}
+}
+
+// url maps a cache key to its location on the server. Keys are hashed
+// again so they have the 64 hex digits Bazel servers expect.
+func (b *remoteBackend) url(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return b.base + b.prefix + hex.EncodeToString(h[:])
+}
+
+func (b *remoteBackend) Get(key string) ([]byte, bool) {
+	if data, ok := b.local.Get(key); ok {
+		return data, true
+	}
+	if b.failed.Load() {
+		return nil, false
+	}
+
+	resp, err := b.client.Get(b.url(key))
+	if err != nil {
+		b.fail(err)
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false
+	case resp.StatusCode != http.StatusOK:
+		b.fail(fmt.Errorf("GET %s: %s", b.url(key), resp.Status))
+		return nil, false
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, remoteMaxEntrySize))
+	if err != nil {
+		b.fail(err)
+		return nil, false
+	}
+	data, ok := openRemoteEntry(raw)
+	if !ok {
+		return nil, false // corrupt entries are treated as misses
+	}
+	b.local.Put(key, data)
+	return data, true
+}
+
+func (b *remoteBackend) Put(key string, data []byte) {
+	b.local.Put(key, data)
+	if b.readOnly || b.failed.Load() {
+		return
+	}
+	b.mu.Lock()
+	b.pending[key] = data
+	b.mu.Unlock()
+}
+
+// Flush writes the local cache back and uploads the entries stored
+// during the run.
+func (b *remoteBackend) Flush() error {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[string][]byte)
+	b.mu.Unlock()
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, remoteUploads)
+	)
+	for key, data := range pending {
+		if b.failed.Load() {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			b.upload(key, data)
+		}()
+	}
+	wg.Wait()
+
+	return b.local.Flush()
+}
+
+func (b *remoteBackend) upload(key string, data []byte) {
+	req, err := http.NewRequest(http.MethodPut, b.url(key), bytes.NewReader(sealRemoteEntry(data)))
+	if err != nil {
+		b.fail(err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		b.fail(err)
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b.fail(fmt.Errorf("PUT %s: %s", b.url(key), resp.Status))
+	}
+}
+
+// fail disables the remote cache for the rest of the run.
+func (b *remoteBackend) fail(err error) {
+	if b.failed.CompareAndSwap(false, true) {
+		_, _ = fmt.Fprintf(os.Stderr, "glint: remote cache unavailable, using the local cache only: %v\n", err)
+	}
+}
+
+func (b *remoteBackend) Clear() error                                { return b.local.Clear() }
+func (b *remoteBackend) Usage() (int, int64, error)                  { return b.local.Usage() }
+func (b *remoteBackend) Prune(n int64, d time.Duration) (int, error) { return b.local.Prune(n, d) }
+
+func sealRemoteEntry(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	out := make([]byte, 0, len(remoteMagic)+len(sum)+len(data))
+	out = append(out, remoteMagic...)
+	out = append(out, sum[:]...)
+	return append(out, data...)
+}
+
+func openRemoteEntry(raw []byte) ([]byte, bool) {
+	header := len(remoteMagic) + sha256.Size
+	if len(raw) < header || string(raw[:len(remoteMagic)]) != remoteMagic {
+		return nil, false
+	}
+	data := raw[header:]
+	sum := sha256.Sum256(data)
+	if !bytes.Equal(sum[:], raw[len(remoteMagic):header]) {
+		return nil, false
+	}
+	return data, true
+}
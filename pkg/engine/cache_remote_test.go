@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicholas/glint/pkg/config"
+)
+
+// fakeRemote is an in-memory HTTP cache server.
+type fakeRemote struct {
+	mu      sync.Mutex
+	entries map[string][]byte // by URL path
+	gets    int
+	puts    int
+	status  int           // if non-zero, the status of every response
+	delay   time.Duration // before every response
+}
+
+func newFakeRemote(t *testing.T) (*fakeRemote, *httptest.Server) {
+	f := &fakeRemote{entries: make(map[string][]byte)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeRemote) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	status, delay := f.status, f.delay
+	switch r.Method {
+	case http.MethodGet:
+		f.gets++
+	case http.MethodPut:
+		f.puts++
+	}
+	f.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.entries[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.entries[r.URL.Path] = data
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeRemote) counts() (gets, puts int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.gets, f.puts
+}
+
+func newTestRemote(t *testing.T, cfg config.RemoteCacheConfig) *remoteBackend {
+	return newRemoteBackend(newDirBackend(t.TempDir()), cfg)
+}
+
+func TestRemoteRoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		layout string
+		prefix string
+	}{
+		{"default", "", "/cache/"},
+		{"gradle", "gradle", "/cache/"},
+		{"bazel", "bazel", "/ac/"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newFakeRemote(t)
+			cfg := config.RemoteCacheConfig{URL: srv.URL + "/", Layout: tt.layout}
+
+			writer := newTestRemote(t, cfg)
+			writer.Put("key", []byte("result"))
+			if err := writer.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if len(f.entries) != 1 {
+				t.Fatalf("server has %d entries, want 1", len(f.entries))
+			}
+			for path := range f.entries {
+				if !strings.HasPrefix(path, tt.prefix) || len(path) != len(tt.prefix)+64 {
+					t.Errorf("entry stored at %s, want %s<sha256>", path, tt.prefix)
+				}
+			}
+
+			reader := newTestRemote(t, cfg)
+			data, ok := reader.Get("key")
+			if !ok || string(data) != "result" {
+				t.Fatalf("Get = %q, %v; want %q, true", data, ok, "result")
+			}
+			if _, ok := reader.Get("other"); ok {
+				t.Error("Get of a missing key hit")
+			}
+
+			// The fetched entry is kept locally.
+			if data, ok := reader.local.Get("key"); !ok || string(data) != "result" {
+				t.Errorf("local Get = %q, %v; want %q, true", data, ok, "result")
+			}
+		})
+	}
+}
+
+func TestRemoteRejectsBadEntries(t *testing.T) {
+	sealed := sealRemoteEntry([]byte("result"))
+	flipped := bytes.Clone(sealed)
+	flipped[len(flipped)-1] ^= 1
+
+	for _, tt := range []struct {
+		name string
+		raw  []byte
+	}{
+		{"empty", nil},
+		{"truncated header", sealed[:len(remoteMagic)+10]},
+		{"truncated payload", sealed[:len(sealed)-1]},
+		{"corrupt payload", flipped},
+		{"wrong magic", append([]byte("GLINTRC0"), sealed[len(remoteMagic):]...)},
+		{"unsealed", []byte("result")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newFakeRemote(t)
+			b := newTestRemote(t, config.RemoteCacheConfig{URL: srv.URL})
+			f.entries[strings.TrimPrefix(b.url("key"), srv.URL)] = tt.raw
+
+			if data, ok := b.Get("key"); ok {
+				t.Fatalf("Get = %q, true; want a miss", data)
+			}
+			if _, ok := b.local.Get("key"); ok {
+				t.Error("bad entry was stored locally")
+			}
+			if b.failed.Load() {
+				t.Error("bad entry disabled the remote cache")
+			}
+		})
+	}
+}
+
+func TestRemoteReadOnly(t *testing.T) {
+	f, srv := newFakeRemote(t)
+	b := newTestRemote(t, config.RemoteCacheConfig{URL: srv.URL, ReadOnly: true})
+
+	b.Put("key", []byte("result"))
+	if err := b.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, puts := f.counts(); puts != 0 {
+		t.Errorf("read-only backend made %d PUTs", puts)
+	}
+	if data, ok := b.Get("key"); !ok || string(data) != "result" {
+		t.Errorf("Get = %q, %v; want the local entry", data, ok)
+	}
+}
+
+func TestRemoteFallsBackToLocal(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status int
+		delay  time.Duration
+	}{
+		{"5xx", http.StatusServiceUnavailable, 0},
+		{"timeout", 0, time.Minute},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newFakeRemote(t)
+			f.status, f.delay = tt.status, tt.delay
+			b := newTestRemote(t, config.RemoteCacheConfig{URL: srv.URL, Timeout: 50 * time.Millisecond})
+			b.local.Put("local", []byte("cached"))
+
+			if _, ok := b.Get("a"); ok {
+				t.Fatal("Get hit on a failing server")
+			}
+			if !b.failed.Load() {
+				t.Fatal("remote cache still enabled after a failure")
+			}
+			if _, ok := b.Get("b"); ok {
+				t.Fatal("Get hit on a failing server")
+			}
+			if data, ok := b.Get("local"); !ok || string(data) != "cached" {
+				t.Errorf("Get = %q, %v; want the local entry", data, ok)
+			}
+			b.Put("c", []byte("result"))
+			if err := b.Flush(); err != nil {
+				t.Fatal(err)
+			}
+
+			if gets, puts := f.counts(); gets != 1 || puts != 0 {
+				t.Errorf("server saw %d GETs and %d PUTs, want 1 and 0", gets, puts)
+			}
+			if _, ok := b.local.Get("c"); !ok {
+				t.Error("entry not stored locally")
+			}
+		})
+	}
+}
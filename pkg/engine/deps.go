@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// depHasher computes, for each package, a hash of its own files and of
+// everything it transitively imports. With type-checked rules active it
+// goes into every cache key, since a change in a dependency, such as a
+// new function signature, changes what a rule sees in an unchanged file.
+type depHasher struct {
+	memo     map[*packages.Package]string
+	versions map[string]string // GOROOT to Go version
+}
+
+func newDepHasher() *depHasher {
+	return &depHasher{memo: make(map[*packages.Package]string), versions: make(map[string]string)}
+}
+
+// hash returns the dependency hash of pkg. Packages from the module cache
+// are immutable and identified by module path and version, and those of
+// the standard library by GOROOT and Go version; anything else, such as
+// the main module, by file contents.
+func (h *depHasher) hash(pkg *packages.Package) string {
+	if k, ok := h.memo[pkg]; ok {
+		return k
+	}
+
+	buf := []byte(pkg.ID + "\x00")
+	if v := moduleVersion(pkg); v != "" {
+		buf = append(buf, v...)
+	} else if v := h.stdVersion(pkg); v != "" {
+		// The file names still vary with build tags.
+		buf = append(buf, v+"\x00"...)
+		for _, f := range pkg.CompiledGoFiles {
+			buf = append(buf, filepath.Base(f)+"\x00"...)
+		}
+	} else {
+		for _, f := range pkg.CompiledGoFiles {
+			src, err := os.ReadFile(f)
+			if err != nil {
+				// An unreadable file never matches a cached entry.
+				src = []byte(err.Error())
+			}
+			buf = append(buf, filepath.Base(f)+"\x00"+HashFile(src)+"\x00"...)
+		}
+	}
+
+	paths := make([]string, 0, len(pkg.Imports))
+	for path := range pkg.Imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		buf = append(buf, h.hash(pkg.Imports[path])+"\x00"...)
+	}
+
+	k := HashFile(buf)
+	h.memo[pkg] = k
+	return k
+}
+
+// moduleVersion returns "path@version" for packages of a versioned module,
+// or "" for the main module, directory replacements and the standard
+// library.
+func moduleVersion(pkg *packages.Package) string {
+	m := pkg.Module
+	if m == nil {
+		return ""
+	}
+	if m.Replace != nil {
+		m = m.Replace
+	}
+	if m.Version == "" {
+		return ""
+	}
+	return m.Path + "@" + m.Version
+}
+
+// stdVersion returns "std@version GOROOT" for packages of the standard
+// library, or "".
+func (h *depHasher) stdVersion(pkg *packages.Package) string {
+	if pkg.Module != nil || len(pkg.GoFiles) == 0 {
+		return ""
+	}
+	dir := filepath.ToSlash(filepath.Dir(pkg.GoFiles[0]))
+	goroot, ok := strings.CutSuffix(dir, "/src/"+pkg.PkgPath)
+	if !ok {
+		return ""
+	}
+	v, ok := h.versions[goroot]
+	if !ok {
+		v = goVersion(goroot)
+		h.versions[goroot] = v
+	}
+	if v == "" {
+		return ""
+	}
+	return "std@" + v + " " + goroot
+}
+
+// goVersion returns the Go version of the toolchain at goroot, from its
+// VERSION file, or "" if it has none, as in a development tree, whose
+// packages are then hashed by contents.
+func goVersion(goroot string) string {
+	data, err := os.ReadFile(filepath.Join(filepath.FromSlash(goroot), "VERSION"))
+	if err != nil {
+		return ""
+	}
+	v, _, _ := strings.Cut(string(data), "\n")
+	if !strings.HasPrefix(v, "go") {
+		return ""
+	}
+	return strings.TrimSpace(v)
+}
@@ -5,12 +5,19 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
+	"github.com/nicholas/glint/pkg/changeset"
 	"github.com/nicholas/glint/pkg/config"
 	"github.com/nicholas/glint/pkg/loader"
 	"github.com/nicholas/glint/pkg/rule"
+	"github.com/nicholas/glint/pkg/version"
+	"golang.org/x/tools/go/packages"
 )
 
 type Engine struct {
@@ -18,76 +25,273 @@ type Engine struct {
 	rules  []rule.Rule
 	cache  *Cache
 	runner *Runner
+
+	changes *changeset.Set
+
+	// tree resolves the config of directories with config files of their
+	// own, and sets holds the rule sets built from those configs.
+	registry *rule.Registry
+	tree     *config.Tree
+	setsMu   sync.Mutex
+	sets     map[*config.Config]*ruleSet
 }
 
 func New(cfg *config.Config, registry *rule.Registry) (*Engine, error) {
-	needsTypes := false
-
-	allRules := registry.All()
-	sort.Slice(allRules, func(i, j int) bool {
-		return allRules[i].Name() < allRules[j].Name()
-	})
-
-	activeRules := make([]rule.Rule, 0, len(allRules))
-	for _, r := range allRules {
-		rc, exists := cfg.Rules[r.Name()]
-		if exists && !rc.Enabled {
-			continue
-		}
-		if !exists && !cfg.EnableAll {
-			continue
+	root, _ := os.Getwd()
+	rs, err := newRuleSet(cfg, registry, root)
+	if err != nil {
+		return nil, err
+	}
+	if len(rs.rules) == 0 {
+		return nil, fmt.Errorf("no rules enabled; enable rules in .glint.yml or use --enable-all")
+	}
+
+	cache, err := NewCache(cfg.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("initializing cache: %w", err)
+	}
+
+	e := &Engine{
+		cfg:      cfg,
+		rules:    rs.rules,
+		cache:    cache,
+		registry: registry,
+		tree:     config.NewTree(root, cfg),
+		sets:     map[*config.Config]*ruleSet{cfg: rs},
+	}
+
+	runner := newRunner(rs, cache, cfg.Concurrency)
+	runner.ruleSetFor = e.ruleSetFor
+	runner.tolerant = cfg.Load.Tolerant
+	runner.programKey = "program:" + computeRuleSetKey(asRules(rs.programRules), rs.settings)
+	runner.analysis = newAnalysisDriver(rs.analyzerRules)
+	runner.analysisKey = "analysis:" + computeRuleSetKey(asRules(rs.analyzerRules), rs.settings)
+	e.runner = runner
+	return e, nil
+}
+
+func (e *Engine) Run(ctx context.Context, patterns []string) ([]rule.Diagnostic, error) {
+	if e.changes != nil && !e.wholeProgram() {
+		narrowed, err := e.changedPackages(patterns)
+		if err != nil {
+			return nil, err
 		}
-		activeRules = append(activeRules, r)
-		if r.NeedsTypeInfo() {
-			needsTypes = true
+		if len(narrowed) == 0 {
+			return nil, nil
 		}
+		patterns = narrowed
 	}
 
-	if len(activeRules) == 0 {
-		return nil, fmt.Errorf("no rules enabled; enable rules in .glint.yml or use --enable-all")
+	_, diags, err := e.analyze(ctx, patterns)
+	if err != nil {
+		return nil, err
 	}
+	e.finishCache()
+	if e.changes != nil {
+		diags = e.changes.Filter(diags)
+	}
+	return diags, nil
+}
 
-	cacheDir := cfg.Cache.Dir
-	cache, err := NewCache(cacheDir, cfg.Cache.Enabled)
+// analyze loads patterns under each configured build and runs the
+// analysis on them, merging the diagnostics that several builds share.
+func (e *Engine) analyze(ctx context.Context, patterns []string) ([]*packages.Package, []rule.Diagnostic, error) {
+	var (
+		pkgs  []*packages.Package
+		diags []rule.Diagnostic
+	)
+	builds := e.cfg.Load.Builds()
+	for _, b := range builds {
+		result, err := e.load(patterns, e.loadOptions(b))
+		if err != nil {
+			if len(builds) > 1 {
+				return nil, nil, fmt.Errorf("loading packages for %s: %w", b, err)
+			}
+			return nil, nil, fmt.Errorf("loading packages: %w", err)
+		}
+		d, err := e.runner.Run(ctx, result.Packages)
+		if err != nil {
+			return nil, nil, err
+		}
+		pkgs = append(pkgs, result.Packages...)
+		diags = append(diags, d...)
+	}
+	diags = uniqueDiagnostics(diags)
+	sortDiagnostics(diags)
+	return pkgs, diags, nil
+}
+
+// load loads patterns with opts. Packages are loaded with type
+// information if the rules of their directory need it, even when those
+// of the working directory don't.
+func (e *Engine) load(patterns []string, opts loader.Options) (*loader.Result, error) {
+	result, err := loader.Load(patterns, opts)
 	if err != nil {
-		return nil, fmt.Errorf("initializing cache: %w", err)
+		return nil, err
 	}
+	if opts.Mode == loader.LoadSyntax {
+		for _, pkg := range result.Packages {
+			rs, err := e.runner.ruleSetOf(pkg)
+			if err != nil {
+				return nil, err
+			}
+			if rs.needsTypes {
+				opts.Mode = loader.LoadTypes
+				if result, err = loader.Load(patterns, opts); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+	}
+	e.runner.typeAware = opts.Mode == loader.LoadTypes
+	return result, nil
+}
 
-	walker := NewWalker(activeRules)
-	ruleSetKey := computeRuleSetKey(activeRules)
+// loadOptions returns the loader options for build b.
+func (e *Engine) loadOptions(b config.BuildConfig) loader.Options {
+	opts := loader.Options{
+		Mode:        e.LoadMode(),
+		AllowErrors: e.cfg.Load.Tolerant,
+		Tests:       e.cfg.Load.Tests,
+	}
+	if len(b.Tags) > 0 {
+		opts.BuildFlags = []string{"-tags=" + strings.Join(b.Tags, ",")}
+	}
+	if b.GOOS != "" || b.GOARCH != "" {
+		opts.Env = os.Environ()
+		if b.GOOS != "" {
+			opts.Env = append(opts.Env, "GOOS="+b.GOOS)
+		}
+		if b.GOARCH != "" {
+			opts.Env = append(opts.Env, "GOARCH="+b.GOARCH)
+		}
+	}
+	return opts
+}
 
-	_ = needsTypes
+// LoadOptions returns the loader options of the first configured build,
+// for callers that load packages themselves.
+func (e *Engine) LoadOptions() loader.Options {
+	return e.loadOptions(e.cfg.Load.Builds()[0])
+}
 
-	runner := NewRunner(walker, cache, cfg.Concurrency, ruleSetKey)
+// uniqueDiagnostics drops repeats of the same diagnostic, as reported for
+// a file loaded under several builds, keeping the first.
+func uniqueDiagnostics(diags []rule.Diagnostic) []rule.Diagnostic {
+	seen := make(map[string]bool, len(diags))
+	out := diags[:0]
+	for _, d := range diags {
+		if k := diagnosticKey(d); !seen[k] {
+			seen[k] = true
+			out = append(out, d)
+		}
+	}
+	return out
+}
 
-	return &Engine{
-		cfg:    cfg,
-		rules:  activeRules,
-		cache:  cache,
-		runner: runner,
-	}, nil
+// finishCache records cache statistics and applies the eviction policy.
+// Failures only cost cache efficiency, so they are ignored.
+func (e *Engine) finishCache() {
+	maxBytes, _ := e.cfg.Cache.MaxBytes()
+	_ = e.cache.Finish(maxBytes, e.cfg.Cache.MaxAge)
 }
 
-func (e *Engine) Run(ctx context.Context, patterns []string) ([]rule.Diagnostic, error) {
-	needsTypes := false
+// RestrictTo limits Run to diagnostics on lines in the change set.
+func (e *Engine) RestrictTo(changes *changeset.Set) {
+	e.changes = changes
+}
+
+// changedPackages narrows patterns to the packages containing changed
+// files under any of the configured builds. Apart from program rules,
+// every rule only reports within the package it looks at, so diagnostics
+// on changed lines can only come from those packages.
+func (e *Engine) changedPackages(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var out []string
+	for _, b := range e.cfg.Load.Builds() {
+		pkgs, err := loader.List(patterns, e.loadOptions(b))
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range pkgs {
+			path := pkg.PkgPath
+			if pkg.ForTest != "" {
+				path = pkg.ForTest // test variants load through the package
+			}
+			if seen[path] {
+				continue
+			}
+			for _, f := range pkg.CompiledGoFiles {
+				if e.changes.HasFile(f) {
+					seen[path] = true
+					out = append(out, path)
+					break
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// wholeProgram reports whether program rules are active, whose results
+// depend on every package in patterns.
+func (e *Engine) wholeProgram() bool {
+	return len(e.runner.rules.programRules) > 0
+}
+
+// LoadMode reports how much package information the active rules need.
+func (e *Engine) LoadMode() loader.LoadMode {
 	for _, r := range e.rules {
 		if r.NeedsTypeInfo() {
-			needsTypes = true
-			break
+			return loader.LoadTypes
 		}
 	}
+	return loader.LoadSyntax
+}
 
-	mode := loader.LoadSyntax
-	if needsTypes {
-		mode = loader.LoadTypes
+// CheckFile lints a single file outside of Run, e.g. an unsaved editor
+// buffer, bypassing the cache. rctx.Src should hold the file contents.
+// Excluded and generated files get no diagnostics. Only the rules that
+// look at one file at a time run; see CheckPackage.
+func (e *Engine) CheckFile(rctx *rule.Context) ([]rule.Diagnostic, error) {
+	rs, err := e.ruleSetFor(filepath.Dir(rctx.FilePath))
+	if err != nil {
+		return nil, err
+	}
+	if rs.filter.excluded(rctx.FilePath) || !rs.filter.lintGenerated && ast.IsGenerated(rctx.File) {
+		return nil, nil
 	}
+	rctx.RuleOptions = rs.settings.options
+	return finish(rs.walker.Walk(rctx), rs, nil, nil), nil
+}
 
-	result, err := loader.Load(patterns, mode, nil)
+// CheckPackage applies the package rules to pkg outside of Run, e.g. a
+// package whose syntax and types reflect unsaved editor buffers, bypassing
+// the cache. Program rules and go/analysis analyzers, which need the
+// whole program or every dependency analyzed, only run in Run.
+func (e *Engine) CheckPackage(pkg *packages.Package) ([]rule.Diagnostic, error) {
+	rs, err := e.ruleSetFor(packageDir(pkg))
 	if err != nil {
-		return nil, fmt.Errorf("loading packages: %w", err)
+		return nil, err
+	}
+	rules := rs.packageRules
+	if illTyped(pkg) {
+		rules = syntaxOnly(rules)
+	}
+	if len(rules) == 0 {
+		return nil, nil
 	}
 
-	return e.runner.Run(ctx, result.Packages)
+	skip := make(map[string]bool)
+	rs.filter.skip(pkg, skip)
+	pctx := newPackageContext(pkg, rs.settings.options, skip)
+	var diags []rule.Diagnostic
+	for _, pr := range rules {
+		diags = append(diags, pr.CheckPackage(pctx)...)
+	}
+	diags = suppressPackage(pkg, diags)
+	return finish(diags, rs, nil, skip), nil
 }
 
 func (e *Engine) ActiveRules() []rule.Rule {
@@ -98,12 +302,23 @@ func (e *Engine) ClearCache() error {
 	return e.cache.Clear()
 }
 
-func computeRuleSetKey(rules []rule.Rule) string {
+func asRules[R rule.Rule](rules []R) []rule.Rule {
+	out := make([]rule.Rule, len(rules))
+	for i, r := range rules {
+		out[i] = r
+	}
+	return out
+}
+
+// computeRuleSetKey identifies the glint version, the active rules and
+// their resolved options, since all of them change what the rules produce
+// for an unchanged file.
+func computeRuleSetKey(rules []rule.Rule, settings *ruleSettings) string {
 	names := make([]string, 0, len(rules))
 	for _, r := range rules {
-		names = append(names, r.Name())
+		names = append(names, r.Name()+fmt.Sprintf("%v", settings.options[r.Name()]))
 	}
 	sort.Strings(names)
-	h := sha256.Sum256([]byte(strings.Join(names, ",")))
+	h := sha256.Sum256([]byte(version.Version + "\x00" + strings.Join(names, ",")))
 	return hex.EncodeToString(h[:8])
 }
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"go/ast"
+	"path/filepath"
+
+	"github.com/nicholas/glint/pkg/config"
+	"github.com/nicholas/glint/pkg/glob"
+	"golang.org/x/tools/go/packages"
+)
+
+// fileFilter decides which files glint reports on, from the include and
+// exclude patterns of the config and of each rule.
+type fileFilter struct {
+	root          string
+	include       []string
+	exclude       []string
+	rules         map[string]config.RuleConfig
+	lintGenerated bool
+}
+
+func newFileFilter(cfg *config.Config, root string) *fileFilter {
+	f := &fileFilter{
+		root:          root,
+		include:       cfg.Include,
+		exclude:       cfg.Exclude,
+		rules:         make(map[string]config.RuleConfig),
+		lintGenerated: cfg.LintGenerated,
+	}
+	for name, rc := range cfg.Rules {
+		if len(rc.Include) > 0 || len(rc.Exclude) > 0 {
+			f.rules[name] = rc
+		}
+	}
+	return f
+}
+
+// rel returns path relative to the root, slash-separated, for matching.
+func (f *fileFilter) rel(path string) string {
+	if f.root != "" && filepath.IsAbs(path) {
+		if r, err := filepath.Rel(f.root, path); err == nil && filepath.IsLocal(r) {
+			path = r
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// excluded reports whether the global patterns leave path out.
+func (f *fileFilter) excluded(path string) bool {
+	if len(f.include) == 0 && len(f.exclude) == 0 {
+		return false
+	}
+	rel := f.rel(path)
+	if len(f.include) > 0 && !glob.Any(f.include, rel) {
+		return true
+	}
+	return glob.Any(f.exclude, rel)
+}
+
+// skip adds the files of pkg that are excluded or, unless they are linted
+// too, generated to out. Only already parsed syntax is inspected.
+func (f *fileFilter) skip(pkg *packages.Package, out map[string]bool) {
+	for i, path := range pkg.CompiledGoFiles {
+		if f.excluded(path) {
+			out[path] = true
+		} else if !f.lintGenerated && i < len(pkg.Syntax) && ast.IsGenerated(pkg.Syntax[i]) {
+			out[path] = true
+		}
+	}
+}
+
+// ruleExcludes reports whether the patterns of rule name leave path out.
+func (f *fileFilter) ruleExcludes(name, path string) bool {
+	rc, ok := f.rules[name]
+	if !ok {
+		return false
+	}
+	rel := f.rel(path)
+	if len(rc.Include) > 0 && !glob.Any(rc.Include, rel) {
+		return true
+	}
+	return glob.Any(rc.Exclude, rel)
+}
@@ -0,0 +1,244 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/tools/go/packages"
+)
+
+// programCacheKey is the cache entry holding the program rules' results.
+const programCacheKey = "program"
+
+// runPackageRules applies the package rules of each package's rule set to
+// it, in parallel. Results are cached per package, keyed by the contents
+// of its files. Packages with errors only get the rules that need no type
+// information, and bypass the cache.
+func (r *Runner) runPackageRules(ctx context.Context, pkgs []*packages.Package, sets map[*packages.Package]*ruleSet, fileHashes map[string]string, skip map[string]bool) ([]rule.Diagnostic, error) {
+	var (
+		mu  sync.Mutex
+		out []rule.Diagnostic
+	)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(r.concurrency)
+	for _, pkg := range pkgs {
+		rs := sets[pkg]
+		if len(rs.packageRules) == 0 {
+			continue
+		}
+		g.Go(func() error {
+			if gctx.Err() != nil {
+				return gctx.Err()
+			}
+
+			var (
+				diags  []rule.Diagnostic
+				cached bool
+			)
+			rules := rs.packageRules
+			h, hashed := packageHash(pkg, fileHashes)
+			if illTyped(pkg) {
+				rules, hashed = syntaxOnly(rules), false
+			}
+			if hashed {
+				diags, cached = r.cache.Lookup(pkg.ID, h, rs.packageKey)
+			}
+			if !cached {
+				pctx := newPackageContext(pkg, rs.settings.options, skip)
+				for _, pr := range rules {
+					diags = append(diags, pr.CheckPackage(pctx)...)
+				}
+				diags = suppressPackage(pkg, diags)
+				if hashed {
+					r.cache.Store(pkg.ID, h, rs.packageKey, diags)
+				}
+			}
+
+			mu.Lock()
+			out = append(out, diags...)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// runProgramRules applies the program rules to all of pkgs at once. The
+// result is cached as a whole, keyed by the contents of every file. If
+// any package has errors, only the rules that need no type information
+// run and nothing is cached.
+func (r *Runner) runProgramRules(pkgs []*packages.Package, fileHashes map[string]string, skip map[string]bool) []rule.Diagnostic {
+	if len(pkgs) == 0 {
+		return nil
+	}
+
+	sorted := append([]*packages.Package(nil), pkgs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	var (
+		buf    []byte
+		hashed = true
+		rules  = r.rules.programRules
+	)
+	for _, pkg := range sorted {
+		if illTyped(pkg) {
+			rules, hashed = syntaxOnly(rules), false
+			break
+		}
+	}
+	for _, pkg := range sorted {
+		if !hashed {
+			break
+		}
+		h, ok := packageHash(pkg, fileHashes)
+		if !ok {
+			hashed = false
+			break
+		}
+		buf = append(buf, pkg.ID+"\x00"+h+"\x00"...)
+	}
+	h := HashFile(buf)
+	if hashed {
+		if cached, ok := r.cache.Lookup(programCacheKey, h, r.programKey); ok {
+			return cached
+		}
+	}
+
+	options := r.rules.settings.options
+	prog := &rule.ProgramContext{
+		FileSet:     sorted[0].Fset,
+		RuleOptions: options,
+	}
+	for _, pkg := range sorted {
+		prog.Packages = append(prog.Packages, newPackageContext(pkg, options, skip))
+	}
+	var diags []rule.Diagnostic
+	for _, pr := range rules {
+		diags = append(diags, pr.CheckProgram(prog)...)
+	}
+	diags = suppressProgram(sorted, diags)
+
+	if hashed {
+		r.cache.Store(programCacheKey, h, r.programKey, diags)
+	}
+	return diags
+}
+
+// newPackageContext describes pkg to package rules. Files in skip are
+// left out, as is the type information of packages with errors.
+func newPackageContext(pkg *packages.Package, options map[string]rule.Options, skip map[string]bool) *rule.PackageContext {
+	pctx := &rule.PackageContext{
+		Path:        pkg.PkgPath,
+		FileSet:     pkg.Fset,
+		RuleOptions: options,
+	}
+	if !illTyped(pkg) {
+		pctx.TypeInfo, pctx.Pkg = pkg.TypesInfo, pkg.Types
+	}
+	for _, f := range pkg.Syntax {
+		tf := pkg.Fset.File(f.Pos())
+		if tf == nil || skip[tf.Name()] {
+			continue
+		}
+		pctx.Files = append(pctx.Files, f)
+		pctx.FilePaths = append(pctx.FilePaths, tf.Name())
+	}
+	for path := range pkg.Imports {
+		pctx.Imports = append(pctx.Imports, path)
+	}
+	sort.Strings(pctx.Imports)
+	return pctx
+}
+
+// syntaxOnly returns the rules that need no type information.
+func syntaxOnly[R rule.Rule](rules []R) []R {
+	var out []R
+	for _, r := range rules {
+		if !r.NeedsTypeInfo() {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// packageHash combines the cache hashes of a package's files, as recorded
+// by the file walks. It fails if any of them could not be read.
+func packageHash(pkg *packages.Package, fileHashes map[string]string) (string, bool) {
+	var buf []byte
+	for _, f := range pkg.CompiledGoFiles {
+		h, ok := fileHashes[f]
+		if !ok {
+			return "", false
+		}
+		buf = append(buf, filepath.Base(f)+"\x00"+h+"\x00"...)
+	}
+	return HashFile(buf), true
+}
+
+// suppressPackage applies each file's suppression directives to the
+// package-level diagnostics reported in it.
+func suppressPackage(pkg *packages.Package, diags []rule.Diagnostic) []rule.Diagnostic {
+	if len(diags) == 0 {
+		return nil
+	}
+	byFile := make(map[string][]rule.Diagnostic)
+	for _, d := range diags {
+		byFile[d.Pos.Filename] = append(byFile[d.Pos.Filename], d)
+	}
+
+	out := make([]rule.Diagnostic, 0, len(diags))
+	for _, f := range pkg.Syntax {
+		tf := pkg.Fset.File(f.Pos())
+		if tf == nil {
+			continue
+		}
+		fileDiags, ok := byFile[tf.Name()]
+		if !ok {
+			continue
+		}
+		delete(byFile, tf.Name())
+
+		ctx := &rule.Context{
+			File:     f,
+			FileSet:  pkg.Fset,
+			FilePath: tf.Name(),
+		}
+		out = append(out, applySuppressions(ctx, fileDiags, false, nil, false)...)
+	}
+	for _, rest := range byFile {
+		out = append(out, rest...)
+	}
+	return out
+}
+
+// suppressProgram is suppressPackage for diagnostics from any of pkgs.
+func suppressProgram(pkgs []*packages.Package, diags []rule.Diagnostic) []rule.Diagnostic {
+	owner := make(map[string]*packages.Package)
+	for _, pkg := range pkgs {
+		for _, f := range pkg.CompiledGoFiles {
+			owner[f] = pkg
+		}
+	}
+
+	byPkg := make(map[*packages.Package][]rule.Diagnostic)
+	var out []rule.Diagnostic
+	for _, d := range diags {
+		if pkg := owner[d.Pos.Filename]; pkg != nil {
+			byPkg[pkg] = append(byPkg[pkg], d)
+		} else {
+			out = append(out, d)
+		}
+	}
+	for _, pkg := range pkgs {
+		out = append(out, suppressPackage(pkg, byPkg[pkg])...)
+	}
+	return out
+}
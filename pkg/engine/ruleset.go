@@ -0,0 +1,202 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/nicholas/glint/pkg/config"
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/tools/go/packages"
+)
+
+// ruleSet is the configuration in effect for the files of a directory:
+// the rules it enables, set up to run, and how their results are
+// filtered. Program rules and analyzers only run from the working
+// directory's set; other sets can only disable them or change their
+// severity.
+type ruleSet struct {
+	rules  []rule.Rule
+	active map[string]bool
+
+	walker *Walker
+	// syntaxWalker runs the walker rules that need no type information;
+	// it replaces walker on packages with errors when types are loaded.
+	syntaxWalker  *Walker
+	packageRules  []rule.PackageRule
+	programRules  []rule.ProgramRule
+	analyzerRules []rule.AnalyzerRule
+
+	settings   *ruleSettings
+	filter     *fileFilter
+	ruleSetKey string
+	packageKey string
+	needsTypes bool
+}
+
+// SelectRules returns the rules of registry that cfg enables, sorted by
+// name.
+func SelectRules(cfg *config.Config, registry *rule.Registry) []rule.Rule {
+	allRules := registry.All()
+	sort.Slice(allRules, func(i, j int) bool {
+		return allRules[i].Name() < allRules[j].Name()
+	})
+
+	active := make([]rule.Rule, 0, len(allRules))
+	for _, r := range allRules {
+		if ok, _ := Selects(cfg, r); ok {
+			active = append(active, r)
+		}
+	}
+	return active
+}
+
+// Selects reports whether cfg enables r, along with the key of the
+// setting that decides it, or "" if nothing enables r.
+func Selects(cfg *config.Config, r rule.Rule) (bool, string) {
+	name := r.Name()
+	if rc, ok := cfg.Rules[name]; ok && rc.Enabled != nil {
+		return *rc.Enabled, "rules." + name + ".enabled"
+	}
+
+	category := r.Category().String()
+	if slices.Contains(cfg.DisableCategories, category) {
+		return false, "disable_categories"
+	}
+	if slices.ContainsFunc(cfg.DisableTags, func(t string) bool { return rule.HasTag(r, t) }) {
+		return false, "disable_tags"
+	}
+	for _, name := range cfg.Presets {
+		if p, ok := rule.LookupPreset(name); ok && p.Match(r) {
+			return true, "presets"
+		}
+	}
+	if slices.ContainsFunc(cfg.EnableTags, func(t string) bool { return rule.HasTag(r, t) }) {
+		return true, "enable_tags"
+	}
+
+	// go/analysis analyzers are opt-in: enable_all and categories do not
+	// cover them, since most need facts from every dependency. Neither do
+	// rules tagged opt-in, whose reports much code rightly ignores.
+	if _, isAnalyzer := r.(rule.AnalyzerRule); isAnalyzer || rule.HasTag(r, "opt-in") {
+		return false, ""
+	}
+	if slices.Contains(cfg.EnableCategories, category) {
+		return true, "enable_categories"
+	}
+	if cfg.EnableAll {
+		return true, "enable_all"
+	}
+	return false, ""
+}
+
+// newRuleSet sets up the rules cfg enables. root is the directory the
+// include and exclude patterns are relative to.
+func newRuleSet(cfg *config.Config, registry *rule.Registry, root string) (*ruleSet, error) {
+	rs := &ruleSet{
+		rules:  SelectRules(cfg, registry),
+		active: make(map[string]bool),
+		filter: newFileFilter(cfg, root),
+	}
+
+	var walkerRules []rule.Rule
+	for _, r := range rs.rules {
+		rs.active[r.Name()] = true
+		switch r := r.(type) {
+		case rule.AnalyzerRule:
+			rs.analyzerRules = append(rs.analyzerRules, r)
+		case rule.PackageRule:
+			rs.packageRules = append(rs.packageRules, r)
+		case rule.ProgramRule:
+			rs.programRules = append(rs.programRules, r)
+		default:
+			walkerRules = append(walkerRules, r)
+		}
+		if r.NeedsTypeInfo() {
+			rs.needsTypes = true
+		}
+	}
+
+	settings, err := resolveSettings(cfg, rs.rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule configuration: %w", err)
+	}
+	rs.settings = settings
+
+	rs.walker = NewWalker(walkerRules)
+	rs.walker.reportUnused = cfg.Suppressions.ReportUnused
+	rs.walker.partial = len(walkerRules) < len(rs.rules)
+	rs.ruleSetKey = computeRuleSetKey(walkerRules, settings)
+	if rs.walker.reportUnused {
+		rs.ruleSetKey += "+unused"
+		if rs.walker.partial {
+			rs.ruleSetKey += "-partial"
+		}
+	}
+
+	// Packages with type errors only get the rules that work on syntax
+	// alone.
+	rs.syntaxWalker = NewWalker(syntaxOnly(walkerRules))
+	rs.syntaxWalker.reportUnused = rs.walker.reportUnused
+	rs.syntaxWalker.partial = true
+
+	rs.packageKey = "package:" + computeRuleSetKey(asRules(rs.packageRules), settings)
+	return rs, nil
+}
+
+// ruleSetFor returns the rule set in effect in dir, which is the working
+// directory's unless config files below it apply.
+func (e *Engine) ruleSetFor(dir string) (*ruleSet, error) {
+	cfg, err := e.tree.ForDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	e.setsMu.Lock()
+	defer e.setsMu.Unlock()
+	if rs, ok := e.sets[cfg]; ok {
+		return rs, nil
+	}
+	rs, err := newRuleSet(cfg, e.registry, e.tree.Root)
+	if err != nil {
+		return nil, fmt.Errorf("config for %s: %w", dir, err)
+	}
+	e.sets[cfg] = rs
+	return rs, nil
+}
+
+// packageDir returns the directory holding pkg's source files.
+func packageDir(pkg *packages.Package) string {
+	if len(pkg.GoFiles) > 0 {
+		return filepath.Dir(pkg.GoFiles[0])
+	}
+	if len(pkg.CompiledGoFiles) > 0 {
+		return filepath.Dir(pkg.CompiledGoFiles[0])
+	}
+	return ""
+}
+
+// finish drops the diagnostics in excluded files and those of rules that
+// the set of the file's directory disables or excludes from it, and
+// applies that set's severity overrides. owners maps files to their set;
+// other files get def.
+func finish(diags []rule.Diagnostic, def *ruleSet, owners map[string]*ruleSet, excluded map[string]bool) []rule.Diagnostic {
+	out := diags[:0]
+	for _, d := range diags {
+		rs := owners[d.Pos.Filename]
+		if rs == nil {
+			rs = def
+		}
+		if excluded[d.Pos.Filename] || rs.filter.ruleExcludes(d.Rule, d.Pos.Filename) {
+			continue
+		}
+		// Program rules and analyzers report through def.
+		if !rs.active[d.Rule] && def.active[d.Rule] {
+			continue
+		}
+		rs.settings.apply(&d)
+		out = append(out, d)
+	}
+	return out
+}
@@ -13,24 +13,48 @@ import (
 )
 
 type Runner struct {
-	walker      *Walker
 	cache       *Cache
 	concurrency int
-	ruleSetKey  string
+
+	// rules is the rule set of the working directory, which also runs the
+	// program rules and analyzers. ruleSetFor returns the one in effect in
+	// another directory.
+	rules      *ruleSet
+	ruleSetFor func(dir string) (*ruleSet, error)
+
+	programKey  string
+	analysis    *analysisDriver
+	analysisKey string
+
+	// typeAware adds each package's dependency hash to its cache keys.
+	typeAware bool
+	// tolerant reports package errors as typecheck diagnostics.
+	tolerant bool
 }
 
-func NewRunner(walker *Walker, cache *Cache, concurrency int, ruleSetKey string) *Runner {
+func newRunner(rules *ruleSet, cache *Cache, concurrency int) *Runner {
 	if concurrency <= 0 {
 		concurrency = runtime.NumCPU()
 	}
 	return &Runner{
-		walker:      walker,
+		rules:       rules,
 		cache:       cache,
 		concurrency: concurrency,
-		ruleSetKey:  ruleSetKey,
 	}
 }
 
+// ruleSetOf returns the rule set in effect for the files of pkg.
+func (r *Runner) ruleSetOf(pkg *packages.Package) (*ruleSet, error) {
+	dir := packageDir(pkg)
+	if r.ruleSetFor == nil || dir == "" {
+		return r.rules, nil
+	}
+	return r.ruleSetFor(dir)
+}
+
+// skippedHash stands in for the hash of files that are not linted.
+const skippedHash = "skipped"
+
 type fileUnit struct {
 	pkg      *packages.Package
 	fileIdx  int
@@ -43,6 +67,34 @@ func (r *Runner) Run(ctx context.Context, pkgs []*packages.Package) ([]rule.Diag
 	for _, pkg := range pkgs {
 		totalFiles += len(pkg.CompiledGoFiles)
 	}
+
+	// Each package is linted with the rule set of its directory.
+	// Excluded, generated and unparsable files are not linted at all.
+	var (
+		sets     = make(map[*packages.Package]*ruleSet, len(pkgs))
+		owners   = make(map[string]*ruleSet, totalFiles)
+		excluded = make(map[string]bool)
+		skip     = make(map[string]bool)
+	)
+	for _, pkg := range pkgs {
+		rs, err := r.ruleSetOf(pkg)
+		if err != nil {
+			return nil, err
+		}
+		sets[pkg] = rs
+		for _, f := range pkg.CompiledGoFiles {
+			owners[f] = rs
+		}
+		rs.filter.skip(pkg, excluded)
+	}
+	unparsed := unparsedFiles(pkgs)
+	for f := range excluded {
+		skip[f] = true
+	}
+	for f := range unparsed {
+		skip[f] = true
+	}
+
 	units := make([]fileUnit, 0, totalFiles)
 	for _, pkg := range pkgs {
 		for i := range pkg.CompiledGoFiles {
@@ -54,16 +106,36 @@ func (r *Runner) Run(ctx context.Context, pkgs []*packages.Package) ([]rule.Diag
 		}
 	}
 
+	var depKeys map[*packages.Package]string
+	if r.typeAware {
+		h := newDepHasher()
+		depKeys = make(map[*packages.Package]string, len(pkgs))
+		for _, pkg := range pkgs {
+			depKeys[pkg] = h.hash(pkg)
+		}
+	}
+
+	// fileHashes holds the hash each file is cached under, which the
+	// package-level caches build on. They don't see excluded files, so
+	// those are recorded without reading them.
 	var (
-		mu       sync.Mutex
-		allDiags []rule.Diagnostic
+		mu         sync.Mutex
+		allDiags   []rule.Diagnostic
+		fileHashes = make(map[string]string, totalFiles)
 	)
+	for f := range excluded {
+		fileHashes[f] = skippedHash
+	}
 
 	g, gctx := errgroup.WithContext(ctx)
 	g.SetLimit(r.concurrency)
 
 	for idx := range units {
 		u := units[idx]
+		if skip[u.filePath] {
+			continue
+		}
+		rs := sets[u.pkg]
 		g.Go(func() error {
 			if gctx.Err() != nil {
 				return gctx.Err()
@@ -75,7 +147,35 @@ func (r *Runner) Run(ctx context.Context, pkgs []*packages.Package) ([]rule.Diag
 			}
 			fileHash := HashFile(src)
 
-			if cached, ok := r.cache.Lookup(u.filePath, fileHash, r.ruleSetKey); ok {
+			// Results on ill-typed packages are partial, so they bypass
+			// the cache.
+			if r.typeAware && illTyped(u.pkg) {
+				if u.fileIdx >= len(u.pkg.Syntax) {
+					return nil
+				}
+				diags := rs.syntaxWalker.Walk(&rule.Context{
+					File:        u.pkg.Syntax[u.fileIdx],
+					FileSet:     u.pkg.Fset,
+					FileHash:    fileHash,
+					FilePath:    u.filePath,
+					Src:         src,
+					RuleOptions: rs.settings.options,
+				})
+				mu.Lock()
+				allDiags = append(allDiags, diags...)
+				mu.Unlock()
+				return nil
+			}
+
+			cacheHash := fileHash
+			if dk, ok := depKeys[u.pkg]; ok {
+				cacheHash = HashFile([]byte(fileHash + "\x00" + dk))
+			}
+			mu.Lock()
+			fileHashes[u.filePath] = cacheHash
+			mu.Unlock()
+
+			if cached, ok := r.cache.Lookup(u.filePath, cacheHash, rs.ruleSetKey); ok {
 				mu.Lock()
 				allDiags = append(allDiags, cached...)
 				mu.Unlock()
@@ -87,17 +187,18 @@ func (r *Runner) Run(ctx context.Context, pkgs []*packages.Package) ([]rule.Diag
 			}
 
 			rctx := &rule.Context{
-				File:     u.pkg.Syntax[u.fileIdx],
-				FileSet:  u.pkg.Fset,
-				TypeInfo: u.pkg.TypesInfo,
-				Pkg:      u.pkg.Types,
-				FileHash: fileHash,
-				FilePath: u.filePath,
+				File:        u.pkg.Syntax[u.fileIdx],
+				FileSet:     u.pkg.Fset,
+				TypeInfo:    u.pkg.TypesInfo,
+				Pkg:         u.pkg.Types,
+				FileHash:    fileHash,
+				FilePath:    u.filePath,
+				Src:         src,
+				RuleOptions: rs.settings.options,
 			}
 
-			diags := r.walker.Walk(rctx)
-
-			r.cache.Store(u.filePath, fileHash, r.ruleSetKey, diags)
+			diags := rs.walker.Walk(rctx)
+			r.cache.Store(u.filePath, cacheHash, rs.ruleSetKey, diags)
 
 			mu.Lock()
 			allDiags = append(allDiags, diags...)
@@ -110,10 +211,71 @@ func (r *Runner) Run(ctx context.Context, pkgs []*packages.Package) ([]rule.Diag
 		return allDiags, err
 	}
 
+	diags, err := r.runPackageRules(ctx, pkgs, sets, fileHashes, skip)
+	if err != nil {
+		return allDiags, err
+	}
+	allDiags = append(allDiags, diags...)
+	if len(r.rules.programRules) > 0 {
+		allDiags = append(allDiags, r.runProgramRules(pkgs, fileHashes, skip)...)
+	}
+	if r.analysis != nil {
+		diags, err := r.runAnalyzers(ctx, pkgs, fileHashes)
+		if err != nil {
+			return allDiags, err
+		}
+		allDiags = append(allDiags, diags...)
+	}
+	if r.tolerant {
+		allDiags = append(allDiags, loadDiagnostics(pkgs)...)
+	}
+
+	allDiags = finish(allDiags, r.rules, owners, excluded)
 	sortDiagnostics(allDiags)
 	return allDiags, nil
 }
 
+// runAnalyzers applies the go/analysis rules to pkgs, except those with
+// errors. Their results are cached per package, keyed by the contents of
+// all of its files.
+func (r *Runner) runAnalyzers(ctx context.Context, pkgs []*packages.Package, fileHashes map[string]string) ([]rule.Diagnostic, error) {
+	var (
+		out    []rule.Diagnostic
+		todo   []*packages.Package
+		hashes = make(map[*packages.Package]string, len(pkgs))
+	)
+	for _, pkg := range pkgs {
+		if illTyped(pkg) {
+			continue
+		}
+		h, ok := packageHash(pkg, fileHashes)
+		if ok {
+			hashes[pkg] = h
+			if cached, ok := r.cache.Lookup(pkg.ID, h, r.analysisKey); ok {
+				out = append(out, cached...)
+				continue
+			}
+		}
+		todo = append(todo, pkg)
+	}
+	if len(todo) == 0 {
+		return out, nil
+	}
+
+	results, err := r.analysis.run(ctx, todo, r.concurrency)
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range todo {
+		diags := suppressPackage(pkg, results[pkg])
+		if h, ok := hashes[pkg]; ok {
+			r.cache.Store(pkg.ID, h, r.analysisKey, diags)
+		}
+		out = append(out, diags...)
+	}
+	return out, nil
+}
+
 func sortDiagnostics(diags []rule.Diagnostic) {
 	for i := 1; i < len(diags); i++ {
 		for j := i; j > 0 && lessPosition(diags[j].Pos, diags[j-1].Pos); j-- {
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/nicholas/glint/pkg/config"
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+// ruleSettings is the per-rule configuration resolved from the config
+// file: schema-checked options and severity overrides.
+type ruleSettings struct {
+	options    map[string]rule.Options
+	severities map[string]rule.Severity
+}
+
+func resolveSettings(cfg *config.Config, rules []rule.Rule) (*ruleSettings, error) {
+	s := &ruleSettings{
+		options:    make(map[string]rule.Options, len(rules)),
+		severities: make(map[string]rule.Severity),
+	}
+	for _, r := range rules {
+		rc := cfg.Rules[r.Name()]
+
+		opts, err := rule.ResolveOptions(r, rc.Options)
+		if err != nil {
+			return nil, err
+		}
+		s.options[r.Name()] = opts
+
+		if rc.Severity != "" {
+			sev, err := rule.ParseSeverity(rc.Severity)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", r.Name(), err)
+			}
+			s.severities[r.Name()] = sev
+		}
+	}
+	return s, nil
+}
+
+// apply rewrites the severity of d according to config.
+func (s *ruleSettings) apply(d *rule.Diagnostic) {
+	if sev, ok := s.severities[d.Rule]; ok {
+		d.Severity = sev
+	}
+}
@@ -0,0 +1,240 @@
+package engine
+
+import (
+	"go/ast"
+	"go/token"
+	"math"
+	"strings"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+// UnusedSuppressionRule is the rule name attached to diagnostics for
+// suppression directives that did not silence anything.
+const UnusedSuppressionRule = "unused-suppression"
+
+// suppression is one parsed directive and the line range it covers.
+//
+// Supported forms:
+//
+//	//glint:ignore rule-a,rule-b reason   trailing: this line only
+//	//glint:ignore rule-a reason          own line: the next statement or declaration
+//	//glint:file-ignore rule-a reason     the whole file
+//	//nolint:rule-a,rule-b                golangci-lint compatible, same placement rules
+//
+// An empty rule list or the name "all" suppresses every rule.
+type suppression struct {
+	rules    map[string]bool // nil means all rules
+	from, to int
+	pos      token.Position
+	text     string
+	nolint   bool
+	used     bool
+}
+
+func (s *suppression) matches(d rule.Diagnostic) bool {
+	if d.Pos.Line < s.from || d.Pos.Line > s.to {
+		return false
+	}
+	return s.rules == nil || s.rules[d.Rule]
+}
+
+// parseSuppressions extracts suppression directives from the comments of
+// ctx.File. Own-line directives extend over the outermost node that starts
+// on the line after their comment group, so a directive in a function's
+// doc comment covers the whole function.
+func parseSuppressions(ctx *rule.Context) []*suppression {
+	var (
+		out            []*suppression
+		nodeEnds, code map[int]int
+	)
+
+	for _, group := range ctx.File.Comments {
+		groupEnd := ctx.FileSet.Position(group.End()).Line
+		for _, c := range group.List {
+			rules, fileLevel, nolint, ok := parseDirective(c.Text)
+			if !ok {
+				continue
+			}
+			pos := ctx.FileSet.Position(c.Pos())
+			s := &suppression{
+				rules:  rules,
+				pos:    pos,
+				text:   c.Text,
+				nolint: nolint,
+			}
+
+			if fileLevel {
+				s.from, s.to = 1, math.MaxInt
+				out = append(out, s)
+				continue
+			}
+			if nodeEnds == nil {
+				nodeEnds, code = collectLines(ctx)
+			}
+			// A directive after code on its line is trailing.
+			if col, found := code[pos.Line]; found && col <= pos.Column {
+				s.from, s.to = pos.Line, pos.Line
+			} else {
+				next := groupEnd + 1
+				s.from, s.to = pos.Line, next
+				if end, found := nodeEnds[next]; found {
+					s.to = end
+				}
+			}
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// parseDirective recognizes glint and nolint directives. It reports the
+// named rules (nil for all), whether the directive is file-level and
+// whether it uses the nolint syntax.
+func parseDirective(text string) (rules map[string]bool, fileLevel, nolint, ok bool) {
+	var list string
+	switch {
+	case strings.HasPrefix(text, "//glint:file-ignore"):
+		list, fileLevel = firstField(strings.TrimPrefix(text, "//glint:file-ignore"))
+		if !fileLevel {
+			return nil, false, false, false
+		}
+	case strings.HasPrefix(text, "//glint:ignore"):
+		var boundary bool
+		list, boundary = firstField(strings.TrimPrefix(text, "//glint:ignore"))
+		if !boundary {
+			return nil, false, false, false
+		}
+	default:
+		rest := strings.TrimPrefix(strings.TrimPrefix(text, "//"), " ")
+		if !strings.HasPrefix(rest, "nolint") {
+			return nil, false, false, false
+		}
+		rest = strings.TrimPrefix(rest, "nolint")
+		nolint = true
+		if strings.HasPrefix(rest, ":") {
+			list, _ = firstField(" " + rest[1:])
+		} else if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+			return nil, false, false, false
+		}
+	}
+
+	if list != "" && list != "all" {
+		rules = make(map[string]bool)
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				rules[name] = true
+			}
+		}
+		if rules["all"] {
+			rules = nil
+		}
+	}
+	return rules, fileLevel, nolint, true
+}
+
+// firstField returns the first whitespace-separated field of s. The
+// boolean is false when s does not start at a word boundary, e.g. for
+// "//glint:ignored".
+func firstField(s string) (string, bool) {
+	if s != "" && s[0] != ' ' && s[0] != '\t' {
+		return "", false
+	}
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return "", true
+	}
+	return fields[0], true
+}
+
+// collectLines maps each line to the last line of the largest node
+// starting on it, in ends, and to the column where code on it starts, in
+// code. Any code on a line starts or ends a node there, so code holds the
+// smallest column at which one does.
+func collectLines(ctx *rule.Context) (ends, code map[int]int) {
+	ends = make(map[int]int)
+	code = make(map[int]int)
+	column := func(line, col int) {
+		if c, found := code[line]; !found || col < c {
+			code[line] = col
+		}
+	}
+	ast.Inspect(ctx.File, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if _, isComment := n.(*ast.CommentGroup); isComment {
+			return false
+		}
+		start := ctx.FileSet.Position(n.Pos())
+		end := ctx.FileSet.Position(n.End())
+		if end.Line > ends[start.Line] {
+			ends[start.Line] = end.Line
+		}
+		column(start.Line, start.Column)
+		column(end.Line, end.Column)
+		return true
+	})
+	return ends, code
+}
+
+// applySuppressions drops suppressed diagnostics. When reportUnused is
+// set, directives that silenced nothing are reported, provided every rule
+// they name is one this walker runs; other names may belong to tools
+// glint does not know about. Directives naming no rule are only reported
+// when blanket is set, i.e. when diags come from every active rule.
+func applySuppressions(
+	ctx *rule.Context,
+	diags []rule.Diagnostic,
+	reportUnused bool,
+	known map[string]bool,
+	blanket bool,
+) []rule.Diagnostic {
+	sups := parseSuppressions(ctx)
+	if len(sups) == 0 {
+		return diags
+	}
+
+	kept := diags[:0]
+	for _, d := range diags {
+		suppressed := false
+		for _, s := range sups {
+			if s.matches(d) {
+				s.used = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			kept = append(kept, d)
+		}
+	}
+
+	if !reportUnused {
+		return kept
+	}
+	for _, s := range sups {
+		if s.used || !checkable(s, known, blanket) {
+			continue
+		}
+		kept = append(kept, rule.Diagnostic{
+			Rule:     UnusedSuppressionRule,
+			Category: rule.CategoryStyle,
+			Severity: rule.SeverityWarning,
+			Pos:      s.pos,
+			Message:  "suppression directive '" + s.text + "' does not match any diagnostic",
+		})
+	}
+	return kept
+}
+
+func checkable(s *suppression, known map[string]bool, blanket bool) bool {
+	if s.rules == nil {
+		return blanket && !s.nolint
+	}
+	for name := range s.rules {
+		if !known[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+package engine
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+const suppressSrc = `package p // line 1
+
+import "os"
+
+//glint:ignore rule-a own line, covers the whole statement
+var x = f(
+	1,
+) // line 8
+
+func f(int) int { return 0 } //glint:ignore rule-b trailing
+
+// g does things.
+//
+//glint:ignore rule-c in the doc comment, covers the function
+func g() {
+	_ = os.Remove("x")
+} // line 17
+
+func h() {
+	if true { //nolint:rule-d
+		_ = x
+	} //nolint:rule-e after a closing brace
+	//nolint // covers the next statement
+	_ = os.Remove(
+		"y",
+	) // line 26
+	_ = x
+}
+`
+
+// kept returns the diagnostics, given as "rule line", that the directives
+// of src leave.
+func kept(t *testing.T, src string, diags []string, reportUnused bool, known ...string) []string {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var in []rule.Diagnostic
+	for _, d := range diags {
+		var name string
+		var line int
+		if _, err := fmt.Sscanf(d, "%s %d", &name, &line); err != nil {
+			t.Fatal(err)
+		}
+		in = append(in, rule.Diagnostic{Rule: name, Pos: token.Position{Filename: "p.go", Line: line, Column: 1}})
+	}
+	knownSet := make(map[string]bool)
+	for _, k := range known {
+		knownSet[k] = true
+	}
+	// No Src: directives are placed by their positions alone.
+	ctx := &rule.Context{File: f, FileSet: fset, FilePath: "p.go"}
+	var out []string
+	for _, d := range applySuppressions(ctx, in, reportUnused, knownSet, true) {
+		out = append(out, fmt.Sprintf("%s %d", d.Rule, d.Pos.Line))
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestSuppressions(t *testing.T) {
+	tests := []struct {
+		name  string
+		diags []string
+		want  []string
+	}{
+		{"own line covers the next statement",
+			[]string{"rule-a 6", "rule-a 7", "rule-a 8", "rule-a 10", "rule-b 6"},
+			[]string{"rule-a 10", "rule-b 6"}},
+		{"trailing covers its line",
+			[]string{"rule-b 10", "rule-b 11", "rule-b 9"},
+			[]string{"rule-b 11", "rule-b 9"}},
+		{"doc comment covers the declaration",
+			[]string{"rule-c 15", "rule-c 16", "rule-c 17", "rule-c 20"},
+			[]string{"rule-c 20"}},
+		{"trailing nolint after an opening brace",
+			[]string{"rule-d 20", "rule-d 21"},
+			[]string{"rule-d 21"}},
+		{"trailing nolint after a closing brace",
+			[]string{"rule-e 21", "rule-e 22", "rule-e 27"},
+			[]string{"rule-e 21", "rule-e 27"}},
+		{"own-line nolint without rules",
+			[]string{"rule-x 24", "rule-x 25", "rule-x 26", "rule-x 27"},
+			[]string{"rule-x 27"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kept(t, suppressSrc, tt.diags, false); !slices.Equal(got, tt.want) {
+				t.Errorf("kept %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSuppression(t *testing.T) {
+	src := "//glint:file-ignore rule-a,rule-b generated\n\npackage p\n\nvar x = 1\n"
+	got := kept(t, src, []string{"rule-a 5", "rule-b 3", "rule-c 5"}, false)
+	if want := []string{"rule-c 5"}; !slices.Equal(got, want) {
+		t.Errorf("kept %q, want %q", got, want)
+	}
+}
+
+func TestParseDirective(t *testing.T) {
+	tests := []struct {
+		text   string
+		ok     bool
+		rules  []string // nil for all
+		file   bool
+		nolint bool
+	}{
+		{"//glint:ignore rule-a reason", true, []string{"rule-a"}, false, false},
+		{"//glint:ignore rule-a,rule-b", true, []string{"rule-a", "rule-b"}, false, false},
+		{"//glint:ignore", true, nil, false, false},
+		{"//glint:ignore all", true, nil, false, false},
+		{"//glint:ignore rule-a,all", true, nil, false, false},
+		{"//glint:ignored rule-a", false, nil, false, false},
+		{"//glint:file-ignore rule-a", true, []string{"rule-a"}, true, false},
+		{"//glint:file-ignored", false, nil, false, false},
+		{"//nolint", true, nil, false, true},
+		{"// nolint:rule-a,rule-b // reason", true, []string{"rule-a", "rule-b"}, false, true},
+		{"//nolint:rule-a reason", true, []string{"rule-a"}, false, true},
+		{"//nolintx", false, nil, false, false},
+		{"// a comment", false, nil, false, false},
+	}
+	for _, tt := range tests {
+		rules, file, nolint, ok := parseDirective(tt.text)
+		var names []string
+		for name := range rules {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		if ok != tt.ok || file != tt.file || nolint != tt.nolint || !slices.Equal(names, tt.rules) || (rules == nil) != (tt.rules == nil) {
+			t.Errorf("parseDirective(%q) = %v, %v, %v, %v; want %v, %v, %v, %v",
+				tt.text, names, file, nolint, ok, tt.rules, tt.file, tt.nolint, tt.ok)
+		}
+	}
+}
+
+func TestUnusedSuppressions(t *testing.T) {
+	src := `package p
+
+//glint:ignore rule-a
+var x = 1
+
+var y = 2 //glint:ignore rule-b
+
+var z = 3 //glint:ignore other-tool
+
+var w = 4 //nolint
+
+var v = 5 //glint:ignore
+`
+	tests := []struct {
+		name         string
+		reportUnused bool
+		diags, want  []string
+	}{
+		{"off", false, nil, nil},
+		{"unused", true, []string{"rule-a 4"},
+			[]string{UnusedSuppressionRule + " 12", UnusedSuppressionRule + " 6"}},
+		{"all used", true, []string{"rule-a 4", "rule-b 6", "rule-c 12"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// other-tool is not a glint rule and a bare nolint may be
+			// meant for other linters, so neither is reported.
+			got := kept(t, src, tt.diags, tt.reportUnused, "rule-a", "rule-b")
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("kept %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/tools/go/packages"
+)
+
+// TypecheckRule is the rule name of diagnostics reporting package load,
+// parse and type errors in tolerant mode.
+const TypecheckRule = "typecheck"
+
+// illTyped reports whether pkg had errors, in which case only rules that
+// need no type information run on it and nothing is cached.
+func illTyped(pkg *packages.Package) bool {
+	return pkg.IllTyped || len(pkg.Errors) > 0
+}
+
+// loadDiagnostics reports the errors of pkgs as typecheck diagnostics.
+func loadDiagnostics(pkgs []*packages.Package) []rule.Diagnostic {
+	var out []rule.Diagnostic
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			pos := parseErrorPos(e.Pos)
+			if pos.Filename == "" && len(pkg.GoFiles) > 0 {
+				pos = token.Position{Filename: pkg.GoFiles[0], Line: 1, Column: 1}
+			}
+			d := rule.Diagnostic{
+				Rule:     TypecheckRule,
+				Category: rule.CategoryBugs,
+				Severity: rule.SeverityError,
+				Pos:      pos,
+				End:      pos,
+				Message:  e.Msg,
+			}
+			if k := diagnosticKey(d); !seen[k] {
+				seen[k] = true
+				out = append(out, d)
+			}
+		}
+	}
+	return out
+}
+
+// unparsedFiles returns the files of pkgs with syntax errors, whose
+// partial ASTs are not worth linting.
+func unparsedFiles(pkgs []*packages.Package) map[string]bool {
+	out := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			if e.Kind == packages.ParseError {
+				if pos := parseErrorPos(e.Pos); pos.Filename != "" {
+					out[pos.Filename] = true
+				}
+			}
+		}
+	}
+	return out
+}
+
+// parseErrorPos parses the "file:line:col" form used by packages.Error;
+// the column, or both numbers, may be missing.
+func parseErrorPos(s string) token.Position {
+	if s == "" || s == "-" {
+		return token.Position{}
+	}
+	var nums []int
+	for len(nums) < 2 {
+		i := strings.LastIndexByte(s, ':')
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			break
+		}
+		nums = append([]int{n}, nums...)
+		s = s[:i]
+	}
+	pos := token.Position{Filename: s}
+	if len(nums) > 0 {
+		pos.Line = nums[0]
+	}
+	if len(nums) > 1 {
+		pos.Column = nums[1]
+	}
+	return pos
+}
@@ -14,6 +14,14 @@ type Walker struct {
 	dispatchTable map[reflect.Type][]rule.Rule
 	fileRules     []rule.FileRule
 	diagPool      sync.Pool
+
+	// known holds the names of the walker's rules, used to decide which
+	// suppression directives can be reported as unused.
+	known        map[string]bool
+	reportUnused bool
+	// partial is set when other active rules report outside the walker,
+	// so directives covering every rule cannot be judged unused here.
+	partial bool
 }
 
 func NewWalker(rules []rule.Rule) *Walker {
@@ -25,9 +33,11 @@ func NewWalker(rules []rule.Rule) *Walker {
 				return &s
 			},
 		},
+		known: make(map[string]bool, len(rules)),
 	}
 
 	for _, r := range rules {
+		w.known[r.Name()] = true
 		if fr, ok := r.(rule.FileRule); ok {
 			w.fileRules = append(w.fileRules, fr)
 		}
@@ -41,7 +51,8 @@ func NewWalker(rules []rule.Rule) *Walker {
 }
 
 // Walk performs a single traversal of the file AST and returns all
-// diagnostics produced by registered rules.
+// diagnostics produced by registered rules, minus those silenced by
+// suppression directives in the file.
 func (w *Walker) Walk(ctx *rule.Context) []rule.Diagnostic {
 	poolVal, _ := w.diagPool.Get().(*[]rule.Diagnostic)
 	if poolVal == nil {
@@ -56,22 +67,26 @@ func (w *Walker) Walk(ctx *rule.Context) []rule.Diagnostic {
 		*buf = append(*buf, fr.CheckFile(ctx)...)
 	}
 
+	// stack holds the ancestors of n; ast.Inspect calls the function with
+	// nil after a node's children, which is where the node is popped.
+	stack := make([]ast.Node, 0, 32)
 	ast.Inspect(ctx.File, func(n ast.Node) bool {
 		if n == nil {
+			stack = stack[:len(stack)-1]
 			return false
 		}
-		t := reflect.TypeOf(n)
-		rules, ok := w.dispatchTable[t]
-		if !ok {
-			return true
-		}
-		for _, r := range rules {
-			*buf = append(*buf, r.Check(ctx, n)...)
+		if rules, ok := w.dispatchTable[reflect.TypeOf(n)]; ok {
+			ctx.Stack = stack
+			for _, r := range rules {
+				*buf = append(*buf, r.Check(ctx, n)...)
+			}
 		}
+		stack = append(stack, n)
 		return true
 	})
+	ctx.Stack = nil
 
 	out := make([]rule.Diagnostic, len(*buf))
 	copy(out, *buf)
-	return out
+	return applySuppressions(ctx, out, w.reportUnused, w.known, !w.partial)
 }
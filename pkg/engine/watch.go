@@ -0,0 +1,289 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nicholas/glint/pkg/loader"
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/tools/go/packages"
+)
+
+// WatchCycle is the outcome of one analysis pass in watch mode.
+type WatchCycle struct {
+	// Diagnostics is the full, current set of diagnostics.
+	Diagnostics []rule.Diagnostic
+	// Added and Resolved are relative to the previous cycle; both are
+	// empty for the first one.
+	Added    []rule.Diagnostic
+	Resolved []rule.Diagnostic
+	// Packages is the number of packages re-analyzed in this cycle.
+	Packages int
+	// Changed lists the files whose modification triggered the cycle.
+	Changed []string
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchState tracks the loaded packages and per-file diagnostics between
+// cycles so that only affected packages have to be reloaded.
+type watchState struct {
+	pkgs   map[string]*packages.Package // by package ID
+	byFile map[string][]rule.Diagnostic
+	stamps map[string]fileStamp
+}
+
+// Watch analyzes patterns, then polls the packages' directories every
+// interval. When Go files change, it reloads the packages containing them
+// plus, if type information is in use, the loaded packages that depend on
+// them, and re-runs the analysis on those; with program rules active, every
+// package is re-analyzed. Unchanged files are served from the cache.
+// onCycle is called after every pass; Watch returns when ctx is cancelled.
+func (e *Engine) Watch(ctx context.Context, patterns []string, interval time.Duration, onCycle func(WatchCycle)) error {
+	st := &watchState{
+		pkgs:   make(map[string]*packages.Package),
+		byFile: make(map[string][]rule.Diagnostic),
+	}
+
+	pkgs, diags, err := e.analyze(ctx, patterns)
+	if err != nil {
+		return err
+	}
+	st.replace(pkgs, diags)
+	st.stamps = st.scan()
+	e.finishCache()
+	onCycle(WatchCycle{Diagnostics: st.all(), Packages: len(pkgs)})
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		stamps := st.scan()
+		changed := changedFiles(st.stamps, stamps)
+		st.stamps = stamps
+		if len(changed) == 0 {
+			continue
+		}
+
+		affected := st.affected(changed, e.LoadMode() == loader.LoadTypes)
+		if len(affected) == 0 {
+			continue
+		}
+		if e.wholeProgram() {
+			affected = st.paths()
+		}
+
+		prev := st.all()
+		pkgs, diags, err := e.analyze(ctx, affected)
+		if err != nil {
+			// Mid-edit files often fail to load; report and wait for
+			// the next change instead of giving up.
+			_, _ = fmt.Fprintf(os.Stderr, "glint: %v\n", err)
+			continue
+		}
+		st.replace(pkgs, diags)
+		e.finishCache()
+
+		cur := st.all()
+		added, resolved := diffDiagnostics(prev, cur)
+		onCycle(WatchCycle{
+			Diagnostics: cur,
+			Added:       added,
+			Resolved:    resolved,
+			Packages:    len(pkgs),
+			Changed:     changed,
+		})
+	}
+}
+
+// replace swaps in freshly analyzed packages and their diagnostics.
+func (st *watchState) replace(pkgs []*packages.Package, diags []rule.Diagnostic) {
+	for _, pkg := range pkgs {
+		if old := st.pkgs[pkg.ID]; old != nil {
+			for _, f := range old.CompiledGoFiles {
+				delete(st.byFile, f)
+			}
+		}
+		for _, f := range pkg.CompiledGoFiles {
+			delete(st.byFile, f)
+		}
+		st.pkgs[pkg.ID] = pkg
+	}
+	for _, d := range diags {
+		st.byFile[d.Pos.Filename] = append(st.byFile[d.Pos.Filename], d)
+	}
+}
+
+func (st *watchState) paths() []string {
+	ids := make(map[string]bool, len(st.pkgs))
+	for id := range st.pkgs {
+		ids[id] = true
+	}
+	return st.patterns(ids)
+}
+
+// patterns returns the import paths to reload the packages with the given
+// IDs by; test variants are reloaded through the package they test.
+func (st *watchState) patterns(ids map[string]bool) []string {
+	seen := make(map[string]bool, len(ids))
+	out := make([]string, 0, len(ids))
+	for id := range ids {
+		pkg := st.pkgs[id]
+		path := pkg.PkgPath
+		if pkg.ForTest != "" {
+			path = pkg.ForTest
+		}
+		if !seen[path] {
+			seen[path] = true
+			out = append(out, path)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (st *watchState) all() []rule.Diagnostic {
+	var out []rule.Diagnostic
+	for _, diags := range st.byFile {
+		out = append(out, diags...)
+	}
+	sortDiagnostics(out)
+	return out
+}
+
+// scan stats the Go files in every watched package directory, so that
+// added and removed files are noticed as well as edits.
+func (st *watchState) scan() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	seen := make(map[string]bool)
+	for _, pkg := range st.pkgs {
+		for _, f := range pkg.GoFiles {
+			dir := filepath.Dir(f)
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				continue
+			}
+			for _, e := range entries {
+				if e.IsDir() || !strings.HasSuffix(e.Name(), ".go") {
+					continue
+				}
+				info, err := e.Info()
+				if err != nil {
+					continue
+				}
+				stamps[filepath.Join(dir, e.Name())] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+			}
+		}
+	}
+	return stamps
+}
+
+func changedFiles(old, cur map[string]fileStamp) []string {
+	var out []string
+	for path, s := range cur {
+		if o, ok := old[path]; !ok || o != s {
+			out = append(out, path)
+		}
+	}
+	for path := range old {
+		if _, ok := cur[path]; !ok {
+			out = append(out, path)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// affected returns the paths of the packages in the directories of the
+// changed files and, when withDependents is set, of every loaded package
+// that transitively imports one of them.
+func (st *watchState) affected(changed []string, withDependents bool) []string {
+	dirs := make(map[string]bool, len(changed))
+	for _, f := range changed {
+		dirs[filepath.Dir(f)] = true
+	}
+
+	hit := make(map[string]bool)
+	for id, pkg := range st.pkgs {
+		for _, f := range pkg.GoFiles {
+			if dirs[filepath.Dir(f)] {
+				hit[id] = true
+				break
+			}
+		}
+	}
+
+	if withDependents {
+		importers := make(map[string][]string)
+		for id, pkg := range st.pkgs {
+			for _, imp := range pkg.Imports {
+				importers[imp.PkgPath] = append(importers[imp.PkgPath], id)
+			}
+		}
+		queue := make([]string, 0, len(hit))
+		for id := range hit {
+			queue = append(queue, id)
+		}
+		for len(queue) > 0 {
+			id := queue[0]
+			queue = queue[1:]
+			for _, imp := range importers[st.pkgs[id].PkgPath] {
+				if !hit[imp] {
+					hit[imp] = true
+					queue = append(queue, imp)
+				}
+			}
+		}
+	}
+	return st.patterns(hit)
+}
+
+func diffDiagnostics(prev, cur []rule.Diagnostic) (added, resolved []rule.Diagnostic) {
+	count := make(map[string]int, len(prev))
+	for _, d := range prev {
+		count[diagnosticKey(d)]++
+	}
+	for _, d := range cur {
+		k := diagnosticKey(d)
+		if count[k] > 0 {
+			count[k]--
+			continue
+		}
+		added = append(added, d)
+	}
+
+	curCount := make(map[string]int, len(cur))
+	for _, d := range cur {
+		curCount[diagnosticKey(d)]++
+	}
+	for _, d := range prev {
+		k := diagnosticKey(d)
+		if curCount[k] > 0 {
+			curCount[k]--
+			continue
+		}
+		resolved = append(resolved, d)
+	}
+	return added, resolved
+}
+
+func diagnosticKey(d rule.Diagnostic) string {
+	return fmt.Sprintf("%s\x00%s\x00%s", d.Rule, d.Pos, d.Message)
+}
@@ -0,0 +1,224 @@
+package fix
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const diffContext = 3
+
+// Diff renders the change as a unified diff.
+func (c FileChange) Diff() string {
+	a := splitLines(c.Old)
+	b := splitLines(c.New)
+	ops := diffLines(a, b)
+	if len(ops) == 0 {
+		return ""
+	}
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", c.Path, c.Path)
+	for _, h := range hunks(ops) {
+		writeHunk(&buf, h, a, b)
+	}
+	return buf.String()
+}
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// op is one line of an edit script; ai and bi index the old and new
+// lines it refers to.
+type op struct {
+	kind   opKind
+	ai, bi int
+}
+
+// maxEdits bounds the edit distance diffLines searches for; beyond it, the
+// changed region is shown as deleted and reinserted as a whole.
+const maxEdits = 2000
+
+// diffLines computes an edit script with Myers' O(ND) algorithm over the
+// region between the common prefix and suffix, which for fixes is small.
+func diffLines(a, b []string) []op {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	if pre == len(a) && pre == len(b) {
+		return nil
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for i := 0; i < pre; i++ {
+		ops = append(ops, op{opEqual, i, i})
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	mid, ok := myers(ma, mb)
+	if !ok {
+		mid = mid[:0]
+		for i := range ma {
+			mid = append(mid, op{opDelete, i, 0})
+		}
+		for j := range mb {
+			mid = append(mid, op{opInsert, len(ma), j})
+		}
+	}
+	for _, o := range mid {
+		ops = append(ops, op{o.kind, pre + o.ai, pre + o.bi})
+	}
+	for k := 0; k < suf; k++ {
+		ops = append(ops, op{opEqual, len(a) - suf + k, len(b) - suf + k})
+	}
+	return ops
+}
+
+// myers returns the shortest edit script turning a into b, or false if it
+// needs more than maxEdits edits. Insertions carry the index in a they
+// come before, deletions the index in b.
+func myers(a, b []string) ([]op, bool) {
+	n, m := len(a), len(b)
+	maxD := min(n+m, maxEdits)
+	off := maxD + 1
+	// v[off+k] is the furthest x reached on diagonal k = x-y; trace[d]
+	// keeps v[-d..d] after d edits, for backtracking.
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, slices.Clone(v[off-d:off+d+1]))
+				return backtrack(trace, n, m), true
+			}
+		}
+		trace = append(trace, slices.Clone(v[off-d:off+d+1]))
+	}
+	return nil, false
+}
+
+func backtrack(trace [][]int, n, m int) []op {
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := func(k int) int { return trace[d-1][k+d-1] }
+		k := x - y
+		pk := k - 1
+		if k == -d || (k != d && prev(k-1) < prev(k+1)) {
+			pk = k + 1
+		}
+		px := prev(pk)
+		py := px - pk
+		for x > px && y > py {
+			x--
+			y--
+			ops = append(ops, op{opEqual, x, y})
+		}
+		if x == px {
+			y--
+			ops = append(ops, op{opInsert, x, y})
+		} else {
+			x--
+			ops = append(ops, op{opDelete, x, y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, op{opEqual, x, y})
+	}
+	slices.Reverse(ops)
+	return ops
+}
+
+// hunks groups the script into runs of changes with surrounding context.
+func hunks(ops []op) [][]op {
+	var out [][]op
+	start, end := -1, -1
+	for k, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		lo, hi := max(k-diffContext, 0), min(k+diffContext+1, len(ops))
+		if start >= 0 && lo <= end {
+			end = hi
+			continue
+		}
+		if start >= 0 {
+			out = append(out, ops[start:end])
+		}
+		start, end = lo, hi
+	}
+	if start >= 0 {
+		out = append(out, ops[start:end])
+	}
+	return out
+}
+
+func writeHunk(buf *strings.Builder, h []op, a, b []string) {
+	var oldLen, newLen int
+	for _, o := range h {
+		if o.kind != opInsert {
+			oldLen++
+		}
+		if o.kind != opDelete {
+			newLen++
+		}
+	}
+	oldStart, newStart := h[0].ai+1, h[0].bi+1
+	if oldLen == 0 {
+		oldStart--
+	}
+	if newLen == 0 {
+		newStart--
+	}
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+	for _, o := range h {
+		switch o.kind {
+		case opEqual:
+			buf.WriteString(" " + a[o.ai])
+		case opDelete:
+			buf.WriteString("-" + a[o.ai])
+		case opInsert:
+			buf.WriteString("+" + b[o.bi])
+		}
+	}
+}
+
+// splitLines splits src into lines that keep their newline; a missing
+// final newline is marked the way diff(1) does.
+func splitLines(src []byte) []string {
+	var lines []string
+	for len(src) > 0 {
+		i := bytes.IndexByte(src, '\n')
+		if i < 0 {
+			lines = append(lines, string(src)+"\n\\ No newline at end of file\n")
+			break
+		}
+		lines = append(lines, string(src[:i+1]))
+		src = src[i+1:]
+	}
+	return lines
+}
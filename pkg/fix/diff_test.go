@@ -0,0 +1,131 @@
+package fix
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// apply runs the edit script ops over a, checking that it is consistent
+// with a and b, and returns the result. An empty script means a and b are
+// equal.
+func apply(t *testing.T, ops []op, a, b []string) []string {
+	t.Helper()
+	if len(ops) == 0 {
+		if strings.Join(a, "\n") != strings.Join(b, "\n") {
+			t.Fatalf("empty script for different a and b")
+		}
+		return a
+	}
+	var out []string
+	ai, bi := 0, 0
+	for _, o := range ops {
+		switch o.kind {
+		case opEqual:
+			if o.ai != ai || o.bi != bi || a[o.ai] != b[o.bi] {
+				t.Fatalf("bad equal op %+v at a[%d], b[%d]", o, ai, bi)
+			}
+			out = append(out, a[o.ai])
+			ai++
+			bi++
+		case opDelete:
+			if o.ai != ai {
+				t.Fatalf("bad delete op %+v at a[%d]", o, ai)
+			}
+			ai++
+		case opInsert:
+			if o.bi != bi {
+				t.Fatalf("bad insert op %+v at b[%d]", o, bi)
+			}
+			out = append(out, b[o.bi])
+			bi++
+		}
+	}
+	if ai != len(a) || bi != len(b) {
+		t.Fatalf("script covers a[:%d] and b[:%d], want all %d and %d lines", ai, bi, len(a), len(b))
+	}
+	return out
+}
+
+func edits(ops []op) int {
+	n := 0
+	for _, o := range ops {
+		if o.kind != opEqual {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		edits int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abc", "abd", 2},
+		{"abc", "acb", 2},
+		{"abcabba", "cbabac", 5},
+		{"aXbXc", "abc", 2},
+		{"abc", "aXbXc", 2},
+	}
+	for _, tt := range tests {
+		a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+		ops := diffLines(a, b)
+		if got := apply(t, ops, a, b); strings.Join(got, "") != tt.b {
+			t.Errorf("diffLines(%q, %q) yields %q", tt.a, tt.b, strings.Join(got, ""))
+		}
+		if n := edits(ops); n != tt.edits {
+			t.Errorf("diffLines(%q, %q) has %d edits, want %d", tt.a, tt.b, n, tt.edits)
+		}
+	}
+}
+
+func TestDiffLinesRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	lines := func(n int) []string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = fmt.Sprint(rng.Intn(4))
+		}
+		return out
+	}
+	for i := 0; i < 500; i++ {
+		a, b := lines(rng.Intn(30)), lines(rng.Intn(30))
+		apply(t, diffLines(a, b), a, b)
+	}
+}
+
+func TestDiffLinesGivesUp(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxEdits; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	a = append([]string{"same"}, append(a, "end")...)
+	b = append([]string{"same"}, append(b, "end")...)
+	ops := diffLines(a, b)
+	apply(t, ops, a, b)
+	if n := edits(ops); n != 2*maxEdits {
+		t.Errorf("%d edits, want %d", n, 2*maxEdits)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := "package p\n\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\nend"
+	new := "package q\n\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\nEND\n"
+	want := "--- p.go\n+++ p.go\n" +
+		"@@ -1,4 +1,4 @@\n-package p\n+package q\n \n 1\n 2\n" +
+		"@@ -11,4 +11,4 @@\n 9\n 10\n 11\n-end\n\\ No newline at end of file\n+END\n"
+	got := FileChange{Path: "p.go", Old: []byte(old), New: []byte(new)}.Diff()
+	if got != want {
+		t.Errorf("Diff:\n%s\nwant:\n%s", got, want)
+	}
+	if d := (FileChange{Path: "p.go", Old: []byte(old), New: []byte(old)}).Diff(); d != "" {
+		t.Errorf("Diff of an unchanged file = %q, want empty", d)
+	}
+}
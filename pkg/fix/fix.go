@@ -0,0 +1,145 @@
+package fix
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+
+	"github.com/nicholas/glint/pkg/fsutil"
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+// FileChange is the fixed content of one file.
+type FileChange struct {
+	Path string
+	Old  []byte
+	New  []byte
+}
+
+// Result describes the outcome of planning fixes for a set of diagnostics.
+type Result struct {
+	Changes []FileChange
+	// Fixed is parallel to the input diagnostics and reports which of
+	// them are resolved by Changes.
+	Fixed []bool
+}
+
+// Plan picks the first suggested fix of every diagnostic, in order, and
+// skips any fix that overlaps an edit already accepted. Each fix is
+// accepted or skipped as a whole. The resulting files are gofmt'ed; a file
+// whose edits would not parse is left untouched.
+func Plan(diags []rule.Diagnostic) (*Result, error) {
+	res := &Result{Fixed: make([]bool, len(diags))}
+	accepted := make(map[string][]rule.TextEdit)
+	owners := make(map[string][]int)
+
+	for i, d := range diags {
+		if len(d.SuggestedFixes) == 0 {
+			continue
+		}
+		edits := d.SuggestedFixes[0].Edits
+		if len(edits) == 0 {
+			continue
+		}
+
+		var fresh []rule.TextEdit
+		ok := true
+		for _, e := range edits {
+			dup, conflict := check(accepted[e.Filename], e)
+			if conflict {
+				ok = false
+				break
+			}
+			if !dup {
+				fresh = append(fresh, e)
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		res.Fixed[i] = true
+		for _, e := range fresh {
+			accepted[e.Filename] = append(accepted[e.Filename], e)
+		}
+		for _, e := range edits {
+			owners[e.Filename] = append(owners[e.Filename], i)
+		}
+	}
+
+	paths := make([]string, 0, len(accepted))
+	for path := range accepted {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		old, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		updated, err := applyEdits(old, accepted[path])
+		if err == nil {
+			updated, err = format.Source(updated)
+		}
+		if err != nil {
+			for _, i := range owners[path] {
+				res.Fixed[i] = false
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "glint: skipping fixes for %s: %v\n", path, err)
+			continue
+		}
+		res.Changes = append(res.Changes, FileChange{Path: path, Old: old, New: updated})
+	}
+	return res, nil
+}
+
+// Write stores every change atomically.
+func (r *Result) Write() error {
+	for _, c := range r.Changes {
+		perm := os.FileMode(0o644)
+		if fi, err := os.Stat(c.Path); err == nil {
+			perm = fi.Mode().Perm()
+		}
+		if err := fsutil.WriteFileAtomic(c.Path, c.New, perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check reports whether e duplicates an accepted edit or overlaps one.
+func check(accepted []rule.TextEdit, e rule.TextEdit) (dup, conflict bool) {
+	for _, a := range accepted {
+		if a == e {
+			return true, false
+		}
+		if e.Start < a.End && a.Start < e.End {
+			return false, true
+		}
+		// Two insertions at the same offset have no defined order.
+		if e.Start == e.End && a.Start == a.End && e.Start == a.Start {
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func applyEdits(src []byte, edits []rule.TextEdit) ([]byte, error) {
+	sorted := make([]rule.TextEdit, len(edits))
+	copy(sorted, edits)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	out := make([]byte, 0, len(src))
+	last := 0
+	for _, e := range sorted {
+		if e.Start < last || e.End < e.Start || e.End > len(src) {
+			return nil, fmt.Errorf("edit [%d,%d) out of range", e.Start, e.End)
+		}
+		out = append(out, src[last:e.Start]...)
+		out = append(out, e.NewText...)
+		last = e.End
+	}
+	return append(out, src[last:]...), nil
+}
@@ -0,0 +1,175 @@
+package fix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+const src = `package p
+
+func f() int {
+	x := int(1)
+	return x
+}
+`
+
+func writeSource(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "p.go")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// replace returns a diagnostic whose fix replaces the first occurrence of
+// old in src with new.
+func replace(path, old, new string) rule.Diagnostic {
+	i := strings.Index(src, old)
+	return rule.Diagnostic{SuggestedFixes: []rule.SuggestedFix{{
+		Edits: []rule.TextEdit{{Filename: path, Start: i, End: i + len(old), NewText: new}},
+	}}}
+}
+
+func insert(path string, at int, text string) rule.Diagnostic {
+	return rule.Diagnostic{SuggestedFixes: []rule.SuggestedFix{{
+		Edits: []rule.TextEdit{{Filename: path, Start: at, End: at, NewText: text}},
+	}}}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name  string
+		diags func(path string) []rule.Diagnostic
+		fixed []bool
+		want  string // "" if the file is left unchanged
+	}{
+		{
+			name: "single",
+			diags: func(path string) []rule.Diagnostic {
+				return []rule.Diagnostic{replace(path, "int(1)", "1")}
+			},
+			fixed: []bool{true},
+			want:  strings.Replace(src, "int(1)", "1", 1),
+		},
+		{
+			name: "overlapping edits keep the first",
+			diags: func(path string) []rule.Diagnostic {
+				return []rule.Diagnostic{
+					replace(path, "int(1)", "1"),
+					replace(path, "(1)", "(2)"),
+					replace(path, "return x", "return x + 1"),
+				}
+			},
+			fixed: []bool{true, false, true},
+			want:  strings.Replace(strings.Replace(src, "int(1)", "1", 1), "return x", "return x + 1", 1),
+		},
+		{
+			name: "duplicate edits apply once",
+			diags: func(path string) []rule.Diagnostic {
+				return []rule.Diagnostic{replace(path, "int(1)", "1"), replace(path, "int(1)", "1")}
+			},
+			fixed: []bool{true, true},
+			want:  strings.Replace(src, "int(1)", "1", 1),
+		},
+		{
+			name: "insertions at the same offset conflict",
+			diags: func(path string) []rule.Diagnostic {
+				at := strings.Index(src, "return")
+				return []rule.Diagnostic{insert(path, at, "x++\n"), insert(path, at, "x--\n")}
+			},
+			fixed: []bool{true, false},
+			want:  strings.Replace(src, "\treturn", "\tx++\n\treturn", 1),
+		},
+		{
+			name: "result is gofmt'ed",
+			diags: func(path string) []rule.Diagnostic {
+				return []rule.Diagnostic{replace(path, "x := int(1)", "x   :=    1")}
+			},
+			fixed: []bool{true},
+			want:  strings.Replace(src, "int(1)", "1", 1),
+		},
+		{
+			name: "unparsable result is skipped",
+			diags: func(path string) []rule.Diagnostic {
+				return []rule.Diagnostic{replace(path, "int(1)", "1"), replace(path, "return x", "return (")}
+			},
+			fixed: []bool{false, false},
+		},
+		{
+			name: "diagnostics without fixes",
+			diags: func(path string) []rule.Diagnostic {
+				return []rule.Diagnostic{{}, replace(path, "int(1)", "1")}
+			},
+			fixed: []bool{false, true},
+			want:  strings.Replace(src, "int(1)", "1", 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeSource(t, src)
+			res, err := Plan(tt.diags(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.fixed {
+				if res.Fixed[i] != want {
+					t.Errorf("Fixed[%d] = %v, want %v", i, res.Fixed[i], want)
+				}
+			}
+			if tt.want == "" {
+				if len(res.Changes) != 0 {
+					t.Errorf("got changes %+v, want none", res.Changes)
+				}
+				return
+			}
+			if len(res.Changes) != 1 {
+				t.Fatalf("got %d changes, want 1", len(res.Changes))
+			}
+			c := res.Changes[0]
+			if c.Path != path || string(c.Old) != src {
+				t.Errorf("change of %s from %q, want %s from the original", c.Path, c.Old, path)
+			}
+			if string(c.New) != tt.want {
+				t.Errorf("fixed source:\n%s\nwant:\n%s", c.New, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	path := writeSource(t, src)
+	res, err := Plan([]rule.Diagnostic{replace(path, "int(1)", "1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(src, "int(1)", "1", 1); string(got) != want {
+		t.Errorf("file holds:\n%s\nwant:\n%s", got, want)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode %v, want the original 0600", fi.Mode().Perm())
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want just the fixed one", len(entries))
+	}
+}
@@ -0,0 +1,42 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	lockPoll = 10 * time.Millisecond
+	// StaleLock is the age past which a lock file is assumed to belong to
+	// a process that died without removing it.
+	StaleLock = time.Minute
+)
+
+// Lock takes an exclusive lock between processes by creating the lock
+// file path, waiting up to timeout for another holder to release it. A
+// lock file older than StaleLock is removed. The returned function
+// releases the lock.
+func Lock(path string, timeout time.Duration) (unlock func(), err error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > StaleLock {
+			_ = os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("locking %s: held by another process", path)
+		}
+		time.Sleep(lockPoll)
+	}
+}
@@ -0,0 +1,120 @@
+// Package glob matches slash-separated file paths against the patterns
+// used in include and exclude lists.
+//
+// Patterns follow the usual gitignore conventions:
+//
+//   - A pattern without a slash, such as *.pb.go or testdata, matches any
+//     file or directory of that name at any depth.
+//   - A pattern with a slash is anchored at the root, and matches the path
+//     itself or any directory containing it: internal/legacy covers every
+//     file below internal/legacy.
+//   - ** matches any number of directories, e.g. **/mocks/*.go.
+//   - A trailing slash, as in vendor/, only matches directories.
+//
+// Within a path element, *, ? and character classes behave as in
+// path.Match.
+package glob
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Validate reports whether pattern is well formed.
+func Validate(pattern string) error {
+	if strings.TrimSuffix(pattern, "/") == "" {
+		return fmt.Errorf("empty pattern %q", pattern)
+	}
+	for _, elem := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if _, err := path.Match(elem, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Match reports whether the slash-separated relative path name matches
+// pattern. Malformed patterns match nothing.
+func Match(pattern, name string) bool {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" {
+		return false
+	}
+	elems := strings.Split(strings.Trim(name, "/"), "/")
+
+	// The path's prefixes of length n are its directories, except the
+	// full path, which is the file itself.
+	last := len(elems)
+	if dirOnly {
+		last--
+	}
+
+	if !strings.Contains(pattern, "/") {
+		for _, e := range elems[:last] {
+			if ok, _ := path.Match(pattern, e); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	pat := strings.Split(pattern, "/")
+	for n := 1; n <= last; n++ {
+		if matchElems(pat, elems[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Under returns the pattern that matches, relative to the root, what
+// pattern matches relative to dir, a slash-separated directory below the
+// root.
+func Under(dir, pattern string) string {
+	dir = strings.Trim(dir, "/")
+	if dir == "" || dir == "." {
+		return pattern
+	}
+	p := strings.Trim(pattern, "/")
+	if !strings.Contains(p, "/") {
+		p = "**/" + p
+	}
+	p = dir + "/" + p
+	if strings.HasSuffix(pattern, "/") {
+		p += "/"
+	}
+	return p
+}
+
+// Any reports whether name matches any of patterns.
+func Any(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchElems(pat, elems []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchElems(pat[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], elems[0]); !ok {
+			return false
+		}
+		pat, elems = pat[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
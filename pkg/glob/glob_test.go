@@ -0,0 +1,131 @@
+package glob
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		// Without a slash: any file or directory of that name.
+		{"*.pb.go", "x.pb.go", true},
+		{"*.pb.go", "a/b/x.pb.go", true},
+		{"*.pb.go", "a/x.go", false},
+		{"testdata", "testdata", true},
+		{"testdata", "pkg/testdata/f.go", true},
+		{"testdata", "pkg/testdatax/f.go", false},
+		{"x?.go", "a/x1.go", true},
+		{"[ab].go", "c/b.go", true},
+		{"[ab].go", "c/c.go", false},
+
+		// With a slash: anchored at the root, covering what's below.
+		{"internal/legacy", "internal/legacy", true},
+		{"internal/legacy", "internal/legacy/a.go", true},
+		{"internal/legacy", "internal/legacy/sub/a.go", true},
+		{"internal/legacy", "x/internal/legacy/a.go", false},
+		{"internal/legacy", "internal/legacyx/a.go", false},
+		{"internal/*.go", "internal/a.go", true},
+		{"internal/*.go", "internal/sub/a.go", false},
+
+		// ** spans any number of directories, including none.
+		{"**/mocks/*.go", "mocks/a.go", true},
+		{"**/mocks/*.go", "a/b/mocks/a.go", true},
+		{"**/mocks/*.go", "a/mocks/sub/a.go", false},
+		{"a/**/z.go", "a/z.go", true},
+		{"a/**/z.go", "a/b/c/z.go", true},
+		{"a/**/z.go", "b/a/z.go", false},
+		{"**", "a/b.go", true},
+
+		// A trailing slash only matches directories.
+		{"vendor/", "vendor/x.go", true},
+		{"vendor/", "a/vendor/x.go", true},
+		{"vendor/", "vendor", false},
+		{"vendor/", "a/vendor", false},
+		{"internal/gen/", "internal/gen/a.go", true},
+		{"internal/gen/", "internal/gen", false},
+
+		// Malformed and empty patterns match nothing.
+		{"[", "[", false},
+		{"a/[", "a/[", false},
+		{"", "a.go", false},
+		{"/", "a.go", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAny(t *testing.T) {
+	patterns := []string{"*.pb.go", "vendor/"}
+	if !Any(patterns, "vendor/a.go") || !Any(patterns, "a/b.pb.go") || Any(patterns, "a/b.go") {
+		t.Errorf("Any(%q) matches the wrong names", patterns)
+	}
+	if Any(nil, "a.go") {
+		t.Error("Any(nil) matched")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ok      bool
+	}{
+		{"*.go", true},
+		{"vendor/", true},
+		{"**/mocks/*.go", true},
+		{"", false},
+		{"/", false},
+		{"[", false},
+		{"a/[b", false},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.pattern); (err == nil) != tt.ok {
+			t.Errorf("Validate(%q) = %v, want ok %v", tt.pattern, err, tt.ok)
+		}
+	}
+}
+
+func TestUnder(t *testing.T) {
+	tests := []struct {
+		dir, pattern, want string
+	}{
+		{"", "*.go", "*.go"},
+		{".", "gen/", "gen/"},
+		{"sub", "*.pb.go", "sub/**/*.pb.go"},
+		{"sub", "testdata", "sub/**/testdata"},
+		{"sub", "gen/x.go", "sub/gen/x.go"},
+		{"sub/", "vendor/", "sub/**/vendor/"},
+		{"a/b", "/x/y/", "a/b/x/y/"},
+		{"a/b", "**/mocks/*.go", "a/b/**/mocks/*.go"},
+	}
+	for _, tt := range tests {
+		if got := Under(tt.dir, tt.pattern); got != tt.want {
+			t.Errorf("Under(%q, %q) = %q, want %q", tt.dir, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// TestUnderMatches checks that a pattern rebased onto a directory matches
+// the paths below it that the pattern matches relative to it, and nothing
+// outside it.
+func TestUnderMatches(t *testing.T) {
+	patterns := []string{"*.pb.go", "testdata", "gen/", "gen/x.go", "internal/legacy", "**/mocks/*.go", "vendor/"}
+	names := []string{
+		"a.pb.go", "x/a.pb.go", "testdata/f.go", "x/testdata/f.go", "gen/x.go", "gen/y.go",
+		"x/gen/y.go", "gen", "internal/legacy/a.go", "x/internal/legacy/a.go", "mocks/m.go",
+		"x/mocks/m.go", "vendor/v.go", "a.go",
+	}
+	for _, p := range patterns {
+		under := Under("sub/dir", p)
+		for _, name := range names {
+			if got, want := Match(under, "sub/dir/"+name), Match(p, name); got != want {
+				t.Errorf("Match(%q, %q) = %v, but Match(%q, %q) = %v", under, "sub/dir/"+name, got, p, name, want)
+			}
+			if Match(under, "other/"+name) || Match(under, "sub/"+name) {
+				t.Errorf("%q matches %q outside sub/dir", under, name)
+			}
+		}
+	}
+}
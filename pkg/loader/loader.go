@@ -2,6 +2,7 @@ package loader
 
 import (
 	"fmt"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -15,6 +16,27 @@ const (
 	LoadTypes
 )
 
+// Options controls how packages are loaded.
+type Options struct {
+	Mode       LoadMode
+	BuildFlags []string
+	// Dir is the directory patterns are resolved in; empty means the
+	// current directory.
+	Dir string
+	// Overlay maps absolute file paths to contents that replace the
+	// files on disk, e.g. unsaved editor buffers.
+	Overlay map[string][]byte
+	// AllowErrors returns packages even when some have errors, for
+	// callers that can work with partial results.
+	AllowErrors bool
+	// Tests loads each package with its _test.go files, plus its
+	// external test package.
+	Tests bool
+	// Env is the environment of the go command, e.g. to set GOOS; nil
+	// means the current one.
+	Env []string
+}
+
 type Result struct {
 	Packages []*packages.Package
 }
@@ -22,12 +44,16 @@ type Result struct {
 // Load loads Go packages at the given patterns. The mode controls
 // whether type information is resolved — skipping it is significantly
 // faster when only AST-level rules are active.
-func Load(patterns []string, mode LoadMode, buildFlags []string) (*Result, error) {
+func Load(patterns []string, opts Options) (*Result, error) {
 	cfg := &packages.Config{
-		BuildFlags: buildFlags,
+		BuildFlags: opts.BuildFlags,
+		Dir:        opts.Dir,
+		Overlay:    opts.Overlay,
+		Tests:      opts.Tests,
+		Env:        opts.Env,
 	}
 
-	switch mode {
+	switch opts.Mode {
 	case LoadSyntax:
 		cfg.Mode = packages.NeedName |
 			packages.NeedFiles |
@@ -43,15 +69,22 @@ func Load(patterns []string, mode LoadMode, buildFlags []string) (*Result, error
 			packages.NeedTypes |
 			packages.NeedTypesInfo |
 			packages.NeedTypesSizes |
+			packages.NeedModule |
 			packages.NeedDeps
 	default:
-		return nil, fmt.Errorf("unknown load mode: %d", mode)
+		return nil, fmt.Errorf("unknown load mode: %d", opts.Mode)
+	}
+	if opts.Tests {
+		cfg.Mode |= packages.NeedForTest
 	}
 
 	pkgs, err := packages.Load(cfg, patterns...)
 	if err != nil {
 		return nil, fmt.Errorf("loading packages: %w", err)
 	}
+	if opts.Tests {
+		pkgs = testVariants(pkgs)
+	}
 
 	errs := make([]error, 0)
 	for _, pkg := range pkgs {
@@ -59,9 +92,52 @@ func Load(patterns []string, mode LoadMode, buildFlags []string) (*Result, error
 			errs = append(errs, e)
 		}
 	}
-	if len(errs) > 0 {
+	if len(errs) > 0 && !opts.AllowErrors {
 		return nil, fmt.Errorf("package errors: %v", errs)
 	}
 
 	return &Result{Packages: pkgs}, nil
 }
+
+// testVariants drops the generated test binaries from pkgs, and the
+// packages whose test variant was loaded too, since the variant holds
+// all of their files. Each file is then linted once.
+func testVariants(pkgs []*packages.Package) []*packages.Package {
+	extended := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.ForTest != "" && pkg.PkgPath == pkg.ForTest {
+			extended[pkg.PkgPath] = true
+		}
+	}
+	out := make([]*packages.Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		switch {
+		case pkg.Name == "main" && strings.HasSuffix(pkg.ID, ".test"):
+		case pkg.ForTest == "" && extended[pkg.PkgPath]:
+		default:
+			out = append(out, pkg)
+		}
+	}
+	return out
+}
+
+// List resolves patterns to packages with only their names and files,
+// which is much cheaper than a full load.
+func List(patterns []string, opts Options) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles,
+		BuildFlags: opts.BuildFlags,
+		Dir:        opts.Dir,
+		Overlay:    opts.Overlay,
+		Tests:      opts.Tests,
+		Env:        opts.Env,
+	}
+	if opts.Tests {
+		cfg.Mode |= packages.NeedForTest
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("listing packages: %w", err)
+	}
+	return pkgs, nil
+}
@@ -0,0 +1,339 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/nicholas/glint/pkg/engine"
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+// Server is a Language Server Protocol server that publishes glint
+// diagnostics for open documents and offers their suggested fixes as
+// code actions.
+type Server struct {
+	conn    *conn
+	ws      *workspace
+	version string
+
+	docs  map[string][]byte
+	diags map[string][]rule.Diagnostic
+	// lastErr is the last error shown to the user, to not repeat it on
+	// every keystroke.
+	lastErr string
+}
+
+func NewServer(eng *engine.Engine, version string) *Server {
+	return &Server{
+		ws:      newWorkspace(eng),
+		version: version,
+		docs:    make(map[string][]byte),
+		diags:   make(map[string][]rule.Diagnostic),
+	}
+}
+
+// Serve handles messages from r until the client sends exit or r is
+// closed. Requests are processed one at a time, in order.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	for {
+		body, err := s.conn.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			_ = s.conn.write(response{
+				JSONRPC: "2.0",
+				Error:   &responseError{Code: codeParseError, Message: err.Error()},
+			})
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, rpcErr := s.handle(&req)
+		if req.ID == nil {
+			continue // notification
+		}
+		resp := response{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rpcErr}
+		if err := s.conn.write(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req *request) (any, *responseError) {
+	switch req.Method {
+	case "initialize":
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync: textDocumentSyncOptions{
+					OpenClose: true,
+					Change:    syncFull,
+					Save:      saveOptions{IncludeText: false},
+				},
+				CodeActionProvider: codeActionOptions{CodeActionKinds: []string{"quickfix"}},
+			},
+			ServerInfo: serverInfo{Name: "glint", Version: s.version},
+		}, nil
+	case "initialized", "shutdown", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.update(p.TextDocument.URI, []byte(p.TextDocument.Text))
+		return nil, nil
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if n := len(p.ContentChanges); n > 0 {
+			s.update(p.TextDocument.URI, []byte(p.ContentChanges[n-1].Text))
+		}
+		return nil, nil
+	case "textDocument/didSave":
+		var p didSaveParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		path := uriToPath(p.TextDocument.URI)
+		if p.Text != nil {
+			s.update(p.TextDocument.URI, []byte(*p.Text))
+		} else if src, ok := s.docs[path]; ok {
+			s.update(p.TextDocument.URI, src)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		path := uriToPath(p.TextDocument.URI)
+		delete(s.docs, path)
+		delete(s.diags, path)
+		s.ws.forget(filepath.Dir(path))
+		s.publish(p.TextDocument.URI, nil, nil)
+		return nil, nil
+	case "textDocument/codeAction":
+		var p codeActionParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.codeActions(p), nil
+	default:
+		if req.ID == nil {
+			return nil, nil
+		}
+		return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// update records the buffer contents for uri, re-lints its package and
+// publishes diagnostics for every open file in it.
+func (s *Server) update(uri string, src []byte) {
+	path := uriToPath(uri)
+	s.docs[path] = src
+
+	results, err := s.ws.analyze(path, s.docs)
+	if err != nil {
+		// Configuration and load errors would otherwise leave the
+		// editor silently without diagnostics.
+		msg := fmt.Sprintf("glint: %s: %v", path, err)
+		_, _ = fmt.Fprintln(os.Stderr, msg)
+		if msg != s.lastErr {
+			s.lastErr = msg
+			_ = s.conn.write(notification{
+				JSONRPC: "2.0",
+				Method:  "window/showMessage",
+				Params:  showMessageParams{Type: messageError, Message: msg},
+			})
+		}
+		return
+	}
+	s.lastErr = ""
+
+	paths := make([]string, 0, len(results))
+	for p := range results {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		s.diags[p] = results[p]
+		s.publish(pathToURI(p), s.docs[p], results[p])
+	}
+}
+
+func (s *Server) publish(uri string, src []byte, diags []rule.Diagnostic) {
+	out := make([]diagnostic, 0, len(diags))
+	for _, d := range diags {
+		out = append(out, toLSPDiagnostic(src, d))
+	}
+	_ = s.conn.write(notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  publishDiagnosticsParams{URI: uri, Diagnostics: out},
+	})
+}
+
+func (s *Server) codeActions(p codeActionParams) []codeAction {
+	path := uriToPath(p.TextDocument.URI)
+	src := s.docs[path]
+
+	actions := make([]codeAction, 0)
+	for _, d := range s.diags[path] {
+		if len(d.SuggestedFixes) == 0 {
+			continue
+		}
+		ld := toLSPDiagnostic(src, d)
+		if !overlaps(ld.Range, p.Range) {
+			continue
+		}
+		for _, fix := range d.SuggestedFixes {
+			edit := &workspaceEdit{Changes: make(map[string][]textEdit)}
+			for _, e := range fix.Edits {
+				esrc := s.docs[e.Filename]
+				if esrc == nil {
+					esrc, _ = os.ReadFile(e.Filename)
+				}
+				euri := pathToURI(e.Filename)
+				edit.Changes[euri] = append(edit.Changes[euri], textEdit{
+					Range:   lspRange{Start: offsetPosition(esrc, e.Start), End: offsetPosition(esrc, e.End)},
+					NewText: e.NewText,
+				})
+			}
+			title := fix.Message
+			if title == "" {
+				title = "Fix: " + d.Message
+			}
+			actions = append(actions, codeAction{
+				Title:       title,
+				Kind:        "quickfix",
+				Diagnostics: []diagnostic{ld},
+				Edit:        edit,
+			})
+		}
+	}
+	return actions
+}
+
+func toLSPDiagnostic(src []byte, d rule.Diagnostic) diagnostic {
+	start := linePosition(src, d.Pos.Line, d.Pos.Column)
+	end := start
+	if d.End.Line > 0 {
+		end = linePosition(src, d.End.Line, d.End.Column)
+	}
+
+	sev := severityInformation
+	switch d.Severity {
+	case rule.SeverityError:
+		sev = severityError
+	case rule.SeverityWarning:
+		sev = severityWarning
+	}
+
+	return diagnostic{
+		Range:    lspRange{Start: start, End: end},
+		Severity: sev,
+		Code:     d.Rule,
+		Source:   "glint",
+		Message:  d.Message,
+	}
+}
+
+// linePosition converts a 1-based line and byte column to an LSP position,
+// whose character offset counts UTF-16 code units.
+func linePosition(src []byte, line, col int) position {
+	if line < 1 {
+		return position{}
+	}
+	start := 0
+	for l := 1; l < line && start < len(src); l++ {
+		i := bytes.IndexByte(src[start:], '\n')
+		if i < 0 {
+			start = len(src)
+			break
+		}
+		start += i + 1
+	}
+	end := start + col - 1
+	if end > len(src) || end < start {
+		end = start
+	}
+	return position{Line: line - 1, Character: utf16Len(src[start:end])}
+}
+
+func offsetPosition(src []byte, offset int) position {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	line, start := 0, 0
+	for i := 0; i < offset; i++ {
+		if src[i] == '\n' {
+			line++
+			start = i + 1
+		}
+	}
+	return position{Line: line, Character: utf16Len(src[start:offset])}
+}
+
+func utf16Len(b []byte) int {
+	n := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		n += utf16.RuneLen(r)
+		b = b[size:]
+	}
+	return n
+}
+
+func overlaps(a, b lspRange) bool {
+	return !before(a.End, b.Start) && !before(b.End, a.Start)
+}
+
+func before(a, b position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// uriToPath returns the file path of a file URI. Windows paths, like
+// file:///C:/src/a.go, lose the slash before the drive letter.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	p := u.Path // unescaped
+	if len(p) > 1 && p[0] == '/' && filepath.VolumeName(p[1:]) != "" {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+func pathToURI(path string) string {
+	p := filepath.ToSlash(path)
+	if filepath.VolumeName(path) != "" {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
@@ -0,0 +1,203 @@
+package lsp
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicholas/glint/pkg/config"
+	"github.com/nicholas/glint/pkg/engine"
+	"github.com/nicholas/glint/pkg/rule"
+	_ "github.com/nicholas/glint/pkg/rules/bugs"
+	_ "github.com/nicholas/glint/pkg/rules/style"
+)
+
+const testConfig = `rules:
+  unchecked-error: {enabled: true}
+  package-doc: {enabled: true}
+`
+
+// client drives a Server over in-memory pipes.
+type client struct {
+	t    *testing.T
+	conn *conn
+	msgs chan map[string]json.RawMessage
+	done chan error
+}
+
+func startServer(t *testing.T, root string) *client {
+	t.Helper()
+	t.Chdir(root)
+	cfg, err := config.Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Cache.Enabled = false
+	eng, err := engine.New(cfg, rule.GlobalRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	c := &client{
+		t:    t,
+		conn: newConn(clientR, clientW),
+		msgs: make(chan map[string]json.RawMessage, 16),
+		done: make(chan error, 1),
+	}
+	go func() {
+		err := NewServer(eng, "test").Serve(serverR, serverW)
+		_ = serverW.Close()
+		c.done <- err
+	}()
+	go func() {
+		defer close(c.msgs)
+		for {
+			body, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			var msg map[string]json.RawMessage
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Errorf("server sent invalid JSON %s: %v", body, err)
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+	t.Cleanup(func() {
+		c.notify("exit", nil)
+		if err := <-c.done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+		_ = clientW.Close()
+	})
+	return c
+}
+
+func (c *client) send(id *int, method string, params any) {
+	c.t.Helper()
+	msg := map[string]any{"jsonrpc": "2.0", "method": method}
+	if id != nil {
+		msg["id"] = *id
+	}
+	if params != nil {
+		msg["params"] = params
+	}
+	if err := c.conn.write(msg); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) notify(method string, params any) { c.send(nil, method, params) }
+
+// next returns the next message from the server with the given method, or
+// the response to a request when method is empty.
+func (c *client) next(method string) map[string]json.RawMessage {
+	c.t.Helper()
+	timeout := time.After(30 * time.Second)
+	for {
+		select {
+		case msg, ok := <-c.msgs:
+			if !ok {
+				c.t.Fatalf("connection closed waiting for %q", method)
+			}
+			var m string
+			_ = json.Unmarshal(msg["method"], &m)
+			if m == method {
+				return msg
+			}
+		case <-timeout:
+			c.t.Fatalf("timed out waiting for %q", method)
+		}
+	}
+}
+
+func (c *client) open(path, text string) {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{
+		URI:     pathToURI(path),
+		Version: 1,
+		Text:    text,
+	}})
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDidOpenPublishesDiagnostics(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":     "module example.com/m\n\ngo 1.21\n",
+		".glint.yml": testConfig,
+		"a.go":       "// Package m does things.\npackage m\n",
+		"b.go":       "// Package m does things.\npackage m\n\nimport \"os\"\n\nfunc F() {\n\tos.Remove(\"x\")\n}\n",
+	})
+	c := startServer(t, root)
+
+	id := 1
+	c.send(&id, "initialize", map[string]any{"rootUri": pathToURI(root)})
+	c.next("")
+
+	// The buffer differs from the file on disk: the call moves down a
+	// line.
+	path := filepath.Join(root, "b.go")
+	c.open(path, "// Package m does things.\npackage m\n\nimport \"os\"\n\nfunc F() {\n\n\tos.Remove(\"x\")\n}\n")
+
+	var p publishDiagnosticsParams
+	if err := json.Unmarshal(c.next("textDocument/publishDiagnostics")["params"], &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.URI != pathToURI(path) {
+		t.Fatalf("diagnostics for %s, want %s", p.URI, pathToURI(path))
+	}
+	got := make(map[string]int)
+	for _, d := range p.Diagnostics {
+		got[d.Code] = d.Range.Start.Line
+	}
+	// Lines are 0-based.
+	want := map[string]int{"unchecked-error": 7, "package-doc": 0}
+	for code, line := range want {
+		if l, ok := got[code]; !ok {
+			t.Errorf("no %s diagnostic in %+v", code, p.Diagnostics)
+		} else if l != line {
+			t.Errorf("%s diagnostic on line %d, want %d", code, l, line)
+		}
+	}
+}
+
+func TestConfigErrorShowsMessage(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":         "module example.com/m\n\ngo 1.21\n",
+		".glint.yml":     testConfig,
+		"sub/.glint.yml": "rules:\n  unchecked-error: {severity: loud}\n",
+		"sub/sub.go":     "package sub\n",
+	})
+	c := startServer(t, root)
+
+	path := filepath.Join(root, "sub", "sub.go")
+	c.open(path, "package sub\n")
+	var p showMessageParams
+	if err := json.Unmarshal(c.next("window/showMessage")["params"], &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != messageError || !strings.Contains(p.Message, "loud") {
+		t.Errorf("showMessage %+v, want an error about the severity", p)
+	}
+}
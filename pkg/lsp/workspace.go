@@ -0,0 +1,263 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/nicholas/glint/pkg/engine"
+	"github.com/nicholas/glint/pkg/loader"
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/tools/go/packages"
+)
+
+// workspace keeps loaded packages warm between edits. The first edit in a
+// directory loads its package through go/packages; later edits re-parse
+// only the changed buffer and re-check the package against the already
+// loaded dependencies. A full reload happens only when the set of imports
+// changes or a new file appears.
+//
+// Each edit runs the file and package rules. Program rules and go/analysis
+// analyzers, which need every package or dependency analyzed, are left to
+// glint run.
+type workspace struct {
+	eng  *engine.Engine
+	mode loader.LoadMode
+	pkgs map[string]*pkgState
+}
+
+type pkgState struct {
+	pkg   *packages.Package
+	paths []string
+	files map[string]*ast.File
+	info  *types.Info
+	types *types.Package
+	base  int // pkg.Fset.Base() after loading
+	// illTyped is set when the files don't parse or type-check, or types
+	// are not loaded.
+	illTyped bool
+}
+
+// maxFileSetGrowth bounds the bytes of source re-parsed into a package's
+// FileSet, which keeps every file ever added, before it is reloaded.
+const maxFileSetGrowth = 64 << 20
+
+var (
+	errStaleImports = errors.New("imports changed")
+	errFileSetFull  = errors.New("file set full")
+)
+
+func newWorkspace(eng *engine.Engine) *workspace {
+	return &workspace{
+		eng:  eng,
+		mode: eng.LoadMode(),
+		pkgs: make(map[string]*pkgState),
+	}
+}
+
+// analyze updates the package containing path with the given buffer
+// contents and lints every open file of that package. It returns
+// diagnostics keyed by file path.
+func (ws *workspace) analyze(path string, docs map[string][]byte) (map[string][]rule.Diagnostic, error) {
+	dir := filepath.Dir(path)
+	st := ws.pkgs[dir]
+
+	var err error
+	if st == nil || st.files[path] == nil {
+		st, err = ws.load(dir, docs)
+	} else if err = st.update(path, docs[path], ws.mode); errors.Is(err, errStaleImports) || errors.Is(err, errFileSetFull) {
+		st, err = ws.load(dir, docs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string][]rule.Diagnostic)
+	if st.files[path] == nil {
+		// Not part of the loaded package, e.g. an external test file;
+		// lint its syntax alone.
+		if out[path], err = ws.lintSyntaxOnly(path, docs[path]); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range st.paths {
+		src, open := docs[p]
+		if !open {
+			continue
+		}
+		rctx := &rule.Context{
+			File:     st.files[p],
+			FileSet:  st.pkg.Fset,
+			TypeInfo: st.info,
+			Pkg:      st.types,
+			FileHash: engine.HashFile(src),
+			FilePath: p,
+			Src:      src,
+		}
+		if out[p], err = ws.eng.CheckFile(rctx); err != nil {
+			return nil, err
+		}
+	}
+
+	diags, err := ws.eng.CheckPackage(st.current())
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range diags {
+		if _, open := docs[d.Pos.Filename]; open {
+			out[d.Pos.Filename] = append(out[d.Pos.Filename], d)
+		}
+	}
+	return out, nil
+}
+
+// forget drops the warm package state for dir so the next edit reloads it.
+func (ws *workspace) forget(dir string) {
+	delete(ws.pkgs, dir)
+}
+
+func (ws *workspace) load(dir string, docs map[string][]byte) (*pkgState, error) {
+	// Build tags and platform follow the config; test variants don't,
+	// since the package is looked up by directory.
+	opts := ws.eng.LoadOptions()
+	res, err := loader.Load([]string{"."}, loader.Options{
+		Mode:        ws.mode,
+		BuildFlags:  opts.BuildFlags,
+		Env:         opts.Env,
+		Dir:         dir,
+		Overlay:     docs,
+		AllowErrors: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Packages) == 0 {
+		return nil, fmt.Errorf("no package in %s", dir)
+	}
+
+	pkg := res.Packages[0]
+	st := &pkgState{
+		pkg:   pkg,
+		files: make(map[string]*ast.File, len(pkg.Syntax)),
+		info:  pkg.TypesInfo,
+		types: pkg.Types,
+		base:  pkg.Fset.Base(),
+
+		illTyped: pkg.IllTyped || len(pkg.Errors) > 0 || ws.mode != loader.LoadTypes,
+	}
+	for i, f := range pkg.Syntax {
+		if i >= len(pkg.CompiledGoFiles) {
+			break
+		}
+		st.paths = append(st.paths, pkg.CompiledGoFiles[i])
+		st.files[pkg.CompiledGoFiles[i]] = f
+	}
+	ws.pkgs[dir] = st
+	return st, nil
+}
+
+// update re-parses path from src and, when types are needed, re-checks
+// the package using the dependencies loaded earlier.
+func (st *pkgState) update(path string, src []byte, mode loader.LoadMode) error {
+	if st.pkg.Fset.Base()-st.base > maxFileSetGrowth {
+		return errFileSetFull
+	}
+	f, parseErr := parser.ParseFile(st.pkg.Fset, path, src, parser.ParseComments|parser.AllErrors)
+	if f == nil {
+		return nil
+	}
+	for _, imp := range f.Imports {
+		ipath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || ipath == "C" || ipath == "unsafe" {
+			continue
+		}
+		if st.pkg.Imports[ipath] == nil {
+			return errStaleImports
+		}
+	}
+	st.files[path] = f
+
+	if mode != loader.LoadTypes {
+		return nil
+	}
+	checkErr := false
+	files := make([]*ast.File, 0, len(st.paths))
+	for _, p := range st.paths {
+		files = append(files, st.files[p])
+	}
+	st.info = newTypesInfo()
+	conf := types.Config{
+		Importer: importerFunc(func(ipath string) (*types.Package, error) {
+			if ipath == "unsafe" {
+				return types.Unsafe, nil
+			}
+			if imp := st.pkg.Imports[ipath]; imp != nil && imp.Types != nil {
+				return imp.Types, nil
+			}
+			return nil, fmt.Errorf("package %s not loaded", ipath)
+		}),
+		Sizes: st.pkg.TypesSizes,
+		// Keep going after the first error; buffers are often mid-edit.
+		Error: func(error) { checkErr = true },
+	}
+	st.types, _ = conf.Check(st.pkg.PkgPath, st.pkg.Fset, files, st.info)
+	st.illTyped = parseErr != nil || checkErr
+	return nil
+}
+
+// current returns the package as of the latest edits.
+func (st *pkgState) current() *packages.Package {
+	pkg := *st.pkg
+	pkg.Syntax = make([]*ast.File, 0, len(st.paths))
+	for _, p := range st.paths {
+		pkg.Syntax = append(pkg.Syntax, st.files[p])
+	}
+	pkg.TypesInfo, pkg.Types = st.info, st.types
+	pkg.IllTyped, pkg.Errors = st.illTyped, nil
+	return &pkg
+}
+
+func (ws *workspace) lintSyntaxOnly(path string, src []byte) ([]rule.Diagnostic, error) {
+	if src == nil {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil
+		}
+		src = data
+	}
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, path, src, parser.ParseComments|parser.AllErrors)
+	if f == nil {
+		return nil, nil
+	}
+	return ws.eng.CheckFile(&rule.Context{
+		File:     f,
+		FileSet:  fset,
+		FileHash: engine.HashFile(src),
+		FilePath: path,
+		Src:      src,
+	})
+}
+
+func newTypesInfo() *types.Info {
+	return &types.Info{
+		Types:        make(map[ast.Expr]types.TypeAndValue),
+		Defs:         make(map[*ast.Ident]types.Object),
+		Uses:         make(map[*ast.Ident]types.Object),
+		Implicits:    make(map[ast.Node]types.Object),
+		Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:       make(map[ast.Node]*types.Scope),
+		Instances:    make(map[*ast.Ident]types.Instance),
+		FileVersions: make(map[*ast.File]string),
+	}
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
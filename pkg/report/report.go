@@ -1,8 +1,12 @@
 package report
 
 import (
+	"bytes"
 	"io"
+	"os"
+	"path/filepath"
 
+	"github.com/nicholas/glint/pkg/fsutil"
 	"github.com/nicholas/glint/pkg/rule"
 )
 
@@ -20,3 +24,16 @@ func New(format string, color bool) Reporter {
 		return &TextReporter{Color: color}
 	}
 }
+
+// WriteFile writes r's report of diagnostics to path atomically, so that
+// readers never see a partial report, creating its directory if needed.
+func WriteFile(r Reporter, path string, diagnostics []rule.Diagnostic) error {
+	var buf bytes.Buffer
+	if err := r.Report(&buf, diagnostics); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, buf.Bytes(), 0o644)
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/nicholas/glint/pkg/rule"
+	"github.com/nicholas/glint/pkg/version"
 )
 
 type SARIFReporter struct{}
@@ -101,7 +102,7 @@ func (r *SARIFReporter) Report(w io.Writer, diagnostics []rule.Diagnostic) error
 			Tool: sarifTool{
 				Driver: sarifDriver{
 					Name:    "glint",
-					Version: "0.1.0",
+					Version: version.Version,
 					Rules:   rules,
 				},
 			},
@@ -21,19 +21,7 @@ type TextReporter struct {
 
 func (r *TextReporter) Report(w io.Writer, diagnostics []rule.Diagnostic) error {
 	for _, d := range diagnostics {
-		if r.Color {
-			sev := colorSeverity(d.Severity)
-			_, _ = fmt.Fprintf(w, "%s%s%s: %s%s%s [%s%s%s] %s\n",
-				colorGray, d.Pos, colorReset,
-				sev, d.Severity, colorReset,
-				colorCyan, d.Rule, colorReset,
-				d.Message,
-			)
-		} else {
-			_, _ = fmt.Fprintf(w, "%s: %s [%s] %s\n",
-				d.Pos, d.Severity, d.Rule, d.Message,
-			)
-		}
+		r.writeDiagnostic(w, "", d)
 	}
 
 	if len(diagnostics) > 0 {
@@ -43,6 +31,35 @@ func (r *TextReporter) Report(w io.Writer, diagnostics []rule.Diagnostic) error
 	return nil
 }
 
+// ReportChanges prints diagnostics that appeared with a "+" prefix and
+// those that went away with a "-" prefix, as watch mode does per cycle.
+func (r *TextReporter) ReportChanges(w io.Writer, added, resolved []rule.Diagnostic) error {
+	for _, d := range resolved {
+		r.writeDiagnostic(w, "- ", d)
+	}
+	for _, d := range added {
+		r.writeDiagnostic(w, "+ ", d)
+	}
+	return nil
+}
+
+func (r *TextReporter) writeDiagnostic(w io.Writer, prefix string, d rule.Diagnostic) {
+	if r.Color {
+		sev := colorSeverity(d.Severity)
+		_, _ = fmt.Fprintf(w, "%s%s%s%s: %s%s%s [%s%s%s] %s\n",
+			prefix,
+			colorGray, d.Pos, colorReset,
+			sev, d.Severity, colorReset,
+			colorCyan, d.Rule, colorReset,
+			d.Message,
+		)
+	} else {
+		_, _ = fmt.Fprintf(w, "%s%s: %s [%s] %s\n",
+			prefix, d.Pos, d.Severity, d.Rule, d.Message,
+		)
+	}
+}
+
 func colorSeverity(s rule.Severity) string {
 	switch s {
 	case rule.SeverityError:
@@ -0,0 +1,176 @@
+package rule
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+type OptionType int
+
+const (
+	OptionInt OptionType = iota
+	OptionBool
+	OptionString
+	OptionStringList
+)
+
+func (t OptionType) String() string {
+	switch t {
+	case OptionInt:
+		return "int"
+	case OptionBool:
+		return "bool"
+	case OptionString:
+		return "string"
+	case OptionStringList:
+		return "[]string"
+	default:
+		return "unknown"
+	}
+}
+
+// OptionSpec declares a single option a rule accepts in the
+// `options:` section of its config entry.
+type OptionSpec struct {
+	Name        string
+	Type        OptionType
+	Default     any
+	Description string
+}
+
+// Configurable is an optional interface for rules that accept options.
+// Options not listed in the schema are rejected when the engine starts.
+type Configurable interface {
+	Rule
+	OptionSchema() []OptionSpec
+}
+
+// Options holds the resolved options for one rule. Values have already
+// been checked against the rule's schema, so the typed getters only fall
+// back to def when the option is absent.
+type Options map[string]any
+
+func (o Options) Int(name string, def int) int {
+	if v, ok := o[name].(int); ok {
+		return v
+	}
+	return def
+}
+
+func (o Options) Bool(name string, def bool) bool {
+	if v, ok := o[name].(bool); ok {
+		return v
+	}
+	return def
+}
+
+func (o Options) String(name string, def string) string {
+	if v, ok := o[name].(string); ok {
+		return v
+	}
+	return def
+}
+
+func (o Options) StringList(name string, def []string) []string {
+	if v, ok := o[name].([]string); ok {
+		return v
+	}
+	return def
+}
+
+// Schema returns the option schema of r, or nil if r takes no options.
+func Schema(r Rule) []OptionSpec {
+	if c, ok := r.(Configurable); ok {
+		return c.OptionSchema()
+	}
+	return nil
+}
+
+// ResolveOptions checks raw against the schema of r and returns the
+// rule's defaults overlaid with the coerced configured values.
+func ResolveOptions(r Rule, raw map[string]any) (Options, error) {
+	schema := Schema(r)
+	specs := make(map[string]OptionSpec, len(schema))
+	out := make(Options, len(schema))
+	for _, spec := range schema {
+		specs[spec.Name] = spec
+		if spec.Default != nil {
+			out[spec.Name] = spec.Default
+		}
+	}
+
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		spec, ok := specs[k]
+		if !ok {
+			if len(specs) == 0 {
+				return nil, fmt.Errorf("rule %s does not accept options (got %q)", r.Name(), k)
+			}
+			return nil, fmt.Errorf("rule %s has no option %q (valid: %s)", r.Name(), k, optionNames(schema))
+		}
+		v, err := CoerceOption(spec.Type, raw[k])
+		if err != nil {
+			return nil, fmt.Errorf("rule %s option %q: %w", r.Name(), k, err)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// CoerceOption converts a decoded YAML value to the Go type used for t.
+func CoerceOption(t OptionType, v any) (any, error) {
+	switch t {
+	case OptionInt:
+		switch n := v.(type) {
+		case int:
+			return n, nil
+		case int64:
+			return int(n), nil
+		case uint64:
+			return int(n), nil
+		case float64:
+			if n == math.Trunc(n) {
+				return int(n), nil
+			}
+		}
+	case OptionBool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case OptionString:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case OptionStringList:
+		switch l := v.(type) {
+		case []string:
+			return l, nil
+		case []any:
+			out := make([]string, 0, len(l))
+			for _, e := range l {
+				s, ok := e.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected %s, got list element %v (%T)", t, e, e)
+				}
+				out = append(out, s)
+			}
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %v (%T)", t, v, v)
+}
+
+func optionNames(schema []OptionSpec) string {
+	names := make([]string, 0, len(schema))
+	for _, spec := range schema {
+		names = append(names, spec.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,55 @@
+package rule
+
+// Preset is a named selection of rules that config can enable at once.
+type Preset struct {
+	Name        string
+	Description string
+	Match       func(Rule) bool
+}
+
+var presets = []Preset{
+	{
+		Name:        "recommended",
+		Description: "rules with few false positives that suit most code",
+		Match:       func(r Rule) bool { return HasTag(r, "recommended") },
+	},
+	{
+		Name:        "strict",
+		Description: "every rule that is not experimental, go vet analyzers included",
+		Match:       func(r Rule) bool { return !HasTag(r, "experimental") },
+	},
+	{
+		Name:        "security",
+		Description: "the security rules",
+		Match:       func(r Rule) bool { return r.Category() == CategorySecurity },
+	},
+	{
+		Name:        "performance",
+		Description: "the performance rules",
+		Match:       func(r Rule) bool { return r.Category() == CategoryPerf },
+	},
+}
+
+// Presets returns the available presets.
+func Presets() []Preset {
+	return presets
+}
+
+// LookupPreset returns the preset called name.
+func LookupPreset(name string) (Preset, bool) {
+	for _, p := range presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// PresetNames returns the names of the available presets.
+func PresetNames() []string {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return names
+}
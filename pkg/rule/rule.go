@@ -1,9 +1,13 @@
 package rule
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
 )
 
 type Severity int
@@ -27,6 +31,20 @@ func (s Severity) String() string {
 	}
 }
 
+// ParseSeverity converts a config severity name to a Severity.
+func ParseSeverity(s string) (Severity, error) {
+	switch s {
+	case "info":
+		return SeverityInfo, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "error":
+		return SeverityError, nil
+	default:
+		return 0, fmt.Errorf("unknown severity %q (valid: info, warning, error)", s)
+	}
+}
+
 type Category int
 
 const (
@@ -51,6 +69,16 @@ func (c Category) String() string {
 	}
 }
 
+// ParseCategory converts a config category name to a Category.
+func ParseCategory(s string) (Category, error) {
+	for c := CategoryBugs; c <= CategorySecurity; c++ {
+		if c.String() == s {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown category %q (valid: bugs, style, perf, security)", s)
+}
+
 type Diagnostic struct {
 	Rule     string
 	Category Category
@@ -58,6 +86,23 @@ type Diagnostic struct {
 	Pos      token.Position
 	End      token.Position
 	Message  string
+	// SuggestedFixes are alternative ways to resolve the diagnostic; only
+	// the first is applied by --fix.
+	SuggestedFixes []SuggestedFix
+}
+
+// SuggestedFix is a set of edits that together resolve a diagnostic.
+type SuggestedFix struct {
+	Message string
+	Edits   []TextEdit
+}
+
+// TextEdit replaces the bytes [Start, End) of Filename with NewText.
+type TextEdit struct {
+	Filename string
+	Start    int
+	End      int
+	NewText  string
 }
 
 type Context struct {
@@ -67,6 +112,32 @@ type Context struct {
 	Pkg      *types.Package
 	FileHash string
 	FilePath string
+	// Src holds the file contents the AST was parsed from, if available.
+	Src []byte
+	// RuleOptions maps rule names to their resolved options.
+	RuleOptions map[string]Options
+	// Stack holds the ancestors of the node passed to Check, outermost
+	// first: Stack[0] is the *ast.File and the last element is the
+	// node's parent. It is only valid for the duration of the call; see
+	// the helpers in stack.go.
+	Stack []ast.Node
+}
+
+// Options returns the resolved options for the named rule. A missing
+// entry yields nil Options, whose getters return their defaults.
+func (c *Context) Options(ruleName string) Options {
+	return c.RuleOptions[ruleName]
+}
+
+// Edit returns a TextEdit replacing the source between pos and end.
+func (c *Context) Edit(pos, end token.Pos, newText string) TextEdit {
+	start := c.FileSet.Position(pos)
+	return TextEdit{
+		Filename: start.Filename,
+		Start:    start.Offset,
+		End:      c.FileSet.Position(end).Offset,
+		NewText:  newText,
+	}
 }
 
 // Rule is the interface that all lint rules must implement.
@@ -89,3 +160,74 @@ type FileRule interface {
 	Rule
 	CheckFile(ctx *Context) []Diagnostic
 }
+
+// PackageContext describes one package for a PackageRule.
+type PackageContext struct {
+	// Path is the package's import path.
+	Path string
+	// Files and FilePaths are parallel: FilePaths[i] is the file Files[i]
+	// was parsed from.
+	Files     []*ast.File
+	FilePaths []string
+	FileSet   *token.FileSet
+	// TypeInfo and Pkg are nil unless type information was loaded.
+	TypeInfo *types.Info
+	Pkg      *types.Package
+	// Imports lists the import paths of the package's direct imports.
+	Imports     []string
+	RuleOptions map[string]Options
+}
+
+// Options returns the resolved options for the named rule.
+func (c *PackageContext) Options(ruleName string) Options {
+	return c.RuleOptions[ruleName]
+}
+
+// ProgramContext describes every loaded package for a ProgramRule.
+type ProgramContext struct {
+	Packages    []*PackageContext
+	FileSet     *token.FileSet
+	RuleOptions map[string]Options
+}
+
+// Options returns the resolved options for the named rule.
+func (c *ProgramContext) Options(ruleName string) Options {
+	return c.RuleOptions[ruleName]
+}
+
+// PackageRule is an optional interface for rules that reason across the
+// files of a package. They run once per package after the file walks,
+// instead of through the walker.
+type PackageRule interface {
+	Rule
+	CheckPackage(ctx *PackageContext) []Diagnostic
+}
+
+// ProgramRule is an optional interface for rules that need every loaded
+// package at once. They run after the package rules.
+type ProgramRule interface {
+	Rule
+	CheckProgram(ctx *ProgramContext) []Diagnostic
+}
+
+// AnalyzerRule is an optional interface for rules backed by a go/analysis
+// analyzer. The engine runs these per package, together with the
+// analyzers they require and their facts, instead of through the walker.
+type AnalyzerRule interface {
+	Rule
+	Analyzer() *analysis.Analyzer
+}
+
+// Tagged is an optional interface for rules that carry free-form tags,
+// such as "recommended", "opinionated" or "experimental", which config
+// can enable and disable rules by.
+type Tagged interface {
+	Rule
+	Tags() []string
+}
+
+// HasTag reports whether r is tagged tag.
+func HasTag(r Rule, tag string) bool {
+	t, ok := r.(Tagged)
+	return ok && slices.Contains(t.Tags(), tag)
+}
@@ -0,0 +1,112 @@
+package rule
+
+import (
+	"go/ast"
+	"path/filepath"
+	"strings"
+)
+
+// Parent returns the parent of the node being checked, or nil at the top
+// of the file.
+func (c *Context) Parent() ast.Node {
+	return c.Ancestor(1)
+}
+
+// Ancestor returns the n-th ancestor of the node being checked: 1 is the
+// parent, 2 the grandparent and so on. It returns nil past the file.
+func (c *Context) Ancestor(n int) ast.Node {
+	if n < 1 || n > len(c.Stack) {
+		return nil
+	}
+	return c.Stack[len(c.Stack)-n]
+}
+
+// EnclosingFunc returns the innermost *ast.FuncDecl or *ast.FuncLit
+// containing the node being checked, or nil.
+func (c *Context) EnclosingFunc() ast.Node {
+	for i := len(c.Stack) - 1; i >= 0; i-- {
+		switch n := c.Stack[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return n
+		}
+	}
+	return nil
+}
+
+// EnclosingFuncDecl returns the top-level function or method containing
+// the node being checked, looking through function literals.
+func (c *Context) EnclosingFuncDecl() *ast.FuncDecl {
+	for i := len(c.Stack) - 1; i >= 0; i-- {
+		if fd, ok := c.Stack[i].(*ast.FuncDecl); ok {
+			return fd
+		}
+	}
+	return nil
+}
+
+// EnclosingLoop returns the innermost *ast.ForStmt or *ast.RangeStmt
+// containing the node being checked within the same function, or nil.
+func (c *Context) EnclosingLoop() ast.Stmt {
+	for i := len(c.Stack) - 1; i >= 0; i-- {
+		switch n := c.Stack[i].(type) {
+		case *ast.ForStmt:
+			return n
+		case *ast.RangeStmt:
+			return n
+		case *ast.FuncDecl, *ast.FuncLit:
+			return nil
+		}
+	}
+	return nil
+}
+
+// EnclosingBlock returns the innermost block containing the node being
+// checked, or nil outside of function bodies.
+func (c *Context) EnclosingBlock() *ast.BlockStmt {
+	for i := len(c.Stack) - 1; i >= 0; i-- {
+		if b, ok := c.Stack[i].(*ast.BlockStmt); ok {
+			return b
+		}
+	}
+	return nil
+}
+
+// InDefer reports whether the node being checked is part of a defer
+// statement, including the body of a deferred function literal.
+func (c *Context) InDefer() bool {
+	for i := len(c.Stack) - 1; i >= 0; i-- {
+		if _, ok := c.Stack[i].(*ast.DeferStmt); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// InGoroutine reports whether the node being checked is part of a go
+// statement, including the body of a function literal it starts.
+func (c *Context) InGoroutine() bool {
+	for i := len(c.Stack) - 1; i >= 0; i-- {
+		if _, ok := c.Stack[i].(*ast.GoStmt); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTestFunc reports whether the node being checked is inside a Test,
+// Benchmark, Fuzz or Example function of a _test.go file.
+func (c *Context) IsTestFunc() bool {
+	if !strings.HasSuffix(filepath.Base(c.FilePath), "_test.go") {
+		return false
+	}
+	fd := c.EnclosingFuncDecl()
+	if fd == nil || fd.Recv != nil {
+		return false
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+		if strings.HasPrefix(fd.Name.Name, prefix) {
+			return true
+		}
+	}
+	return false
+}
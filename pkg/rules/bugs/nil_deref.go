@@ -16,6 +16,7 @@ func (NilDeref) Description() string {
 	return "Detects potential nil pointer dereferences after type assertions or map lookups without ok check"
 }
 func (NilDeref) NeedsTypeInfo() bool { return true }
+func (NilDeref) Tags() []string      { return []string{"recommended"} }
 func (NilDeref) NodeTypes() []ast.Node {
 	return []ast.Node{(*ast.AssignStmt)(nil)}
 }
@@ -16,6 +16,7 @@ func (ShadowVar) Description() string {
 	return "Detects variable shadowing in inner scopes"
 }
 func (ShadowVar) NeedsTypeInfo() bool { return true }
+func (ShadowVar) Tags() []string      { return []string{"opinionated"} }
 func (ShadowVar) NodeTypes() []ast.Node {
 	return []ast.Node{(*ast.AssignStmt)(nil)}
 }
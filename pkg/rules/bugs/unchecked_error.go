@@ -16,6 +16,7 @@ func (UncheckedError) Description() string {
 	return "Detects ignored error return values"
 }
 func (UncheckedError) NeedsTypeInfo() bool { return true }
+func (UncheckedError) Tags() []string      { return []string{"recommended"} }
 func (UncheckedError) NodeTypes() []ast.Node {
 	return []ast.Node{(*ast.ExprStmt)(nil)}
 }
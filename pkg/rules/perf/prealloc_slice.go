@@ -15,138 +15,108 @@ func (PreallocSlice) Severity() rule.Severity { return rule.SeverityWarning }
 func (PreallocSlice) Description() string {
 	return "Suggests preallocating slices that are grown inside loops with append"
 }
-func (PreallocSlice) NeedsTypeInfo() bool  { return true }
-func (PreallocSlice) NodeTypes() []ast.Node { return nil }
-
-func (PreallocSlice) Check(_ *rule.Context, _ ast.Node) []rule.Diagnostic {
-	return nil
+func (PreallocSlice) NeedsTypeInfo() bool { return true }
+func (PreallocSlice) Tags() []string      { return []string{"opinionated"} }
+func (PreallocSlice) NodeTypes() []ast.Node {
+	return []ast.Node{(*ast.AssignStmt)(nil)}
 }
 
-// CheckFile walks the file to find append-in-loop patterns while tracking
-// which slice variables were already preallocated with make().
-func (PreallocSlice) CheckFile(ctx *rule.Context) []rule.Diagnostic {
-	if ctx.TypeInfo == nil {
+// Check reports s = append(s, ...) directly in the body of a loop that is
+// itself a top-level statement of a function, unless s was allocated with
+// a length or capacity earlier in that function.
+func (PreallocSlice) Check(ctx *rule.Context, node ast.Node) []rule.Diagnostic {
+	assign, ok := node.(*ast.AssignStmt)
+	if !ok || ctx.TypeInfo == nil || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
 		return nil
 	}
 
-	var diags []rule.Diagnostic
-
-	ast.Inspect(ctx.File, func(n ast.Node) bool {
-		fn, fnOk := n.(*ast.FuncDecl)
-		if !fnOk || fn.Body == nil {
-			return true
-		}
-		diags = append(diags, checkFuncBody(ctx, fn.Body)...)
-		return false
-	})
-
-	return diags
-}
-
-func checkFuncBody(ctx *rule.Context, body *ast.BlockStmt) []rule.Diagnostic {
-	diags := make([]rule.Diagnostic, 0)
-	preallocated := make(map[string]bool)
-
-	for _, stmt := range body.List {
-		trackMakeAllocations(stmt, preallocated)
-		diags = append(diags, checkStmtForAppendInLoop(ctx, stmt, preallocated)...)
+	call, callOk := assign.Rhs[0].(*ast.CallExpr)
+	if !callOk {
+		return nil
 	}
-
-	return diags
-}
-
-func trackMakeAllocations(stmt ast.Stmt, preallocated map[string]bool) {
-	assign, assignOk := stmt.(*ast.AssignStmt)
-	if !assignOk || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
-		return
+	fnIdent, fnOk := call.Fun.(*ast.Ident)
+	if !fnOk || fnIdent.Name != "append" || len(call.Args) < 1 {
+		return nil
 	}
-
-	ident, identOk := assign.Lhs[0].(*ast.Ident)
-	if !identOk {
-		return
+	lhsIdent, lOk := assign.Lhs[0].(*ast.Ident)
+	argIdent, rOk := call.Args[0].(*ast.Ident)
+	if !lOk || !rOk || lhsIdent.Name != argIdent.Name {
+		return nil
 	}
 
-	call, callOk := assign.Rhs[0].(*ast.CallExpr)
-	if !callOk {
-		return
+	t := ctx.TypeInfo.TypeOf(call.Args[0])
+	if t == nil {
+		return nil
 	}
-
-	fnIdent, fnOk := call.Fun.(*ast.Ident)
-	if !fnOk || fnIdent.Name != "make" {
-		return
+	if _, sliceOk := t.Underlying().(*types.Slice); !sliceOk {
+		return nil
 	}
 
-	// make([]T, len) or make([]T, len, cap) — both count as preallocated
-	if len(call.Args) >= 2 {
-		preallocated[ident.Name] = true
+	// The stack must end in FuncDecl, its body, the loop, the loop body.
+	loop, loopOk := ctx.Ancestor(2).(ast.Stmt)
+	if !loopOk || ctx.EnclosingLoop() != loop || ctx.Parent() != loopBody(loop) {
+		return nil
+	}
+	fn, fnDeclOk := ctx.Ancestor(4).(*ast.FuncDecl)
+	if !fnDeclOk || ctx.Ancestor(3) != fn.Body {
+		return nil
+	}
+	if preallocatedBefore(fn.Body, loop, lhsIdent.Name) {
+		return nil
 	}
-}
 
-func checkStmtForAppendInLoop(
-	ctx *rule.Context,
-	stmt ast.Stmt,
-	preallocated map[string]bool,
-) []rule.Diagnostic {
-	var body *ast.BlockStmt
+	return []rule.Diagnostic{{
+		Rule:     "prealloc-slice",
+		Category: rule.CategoryPerf,
+		Severity: rule.SeverityWarning,
+		Pos:      ctx.FileSet.Position(assign.Pos()),
+		End:      ctx.FileSet.Position(assign.End()),
+		Message:  "consider preallocating '" + lhsIdent.Name + "'",
+	}}
+}
 
-	switch s := stmt.(type) {
+func loopBody(loop ast.Stmt) *ast.BlockStmt {
+	switch s := loop.(type) {
 	case *ast.RangeStmt:
-		body = s.Body
+		return s.Body
 	case *ast.ForStmt:
-		body = s.Body
-	default:
-		return nil
-	}
-
-	if body == nil {
-		return nil
+		return s.Body
 	}
+	return nil
+}
 
-	var diags []rule.Diagnostic
-	for _, loopStmt := range body.List {
-		assign, assignOk := loopStmt.(*ast.AssignStmt)
-		if !assignOk || len(assign.Rhs) != 1 {
-			continue
+// preallocatedBefore reports whether a statement of body preceding loop
+// assigns make([]T, len) or make([]T, len, cap) to name.
+func preallocatedBefore(body *ast.BlockStmt, loop ast.Stmt, name string) bool {
+	for _, stmt := range body.List {
+		if stmt == loop {
+			return false
 		}
-
-		call, callOk := assign.Rhs[0].(*ast.CallExpr)
-		if !callOk {
-			continue
+		if isMakeWithSize(stmt, name) {
+			return true
 		}
+	}
+	return false
+}
 
-		fnIdent, fnOk := call.Fun.(*ast.Ident)
-		if !fnOk || fnIdent.Name != "append" || len(call.Args) < 1 {
-			continue
-		}
+func isMakeWithSize(stmt ast.Stmt, name string) bool {
+	assign, assignOk := stmt.(*ast.AssignStmt)
+	if !assignOk || len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
+		return false
+	}
 
-		t := ctx.TypeInfo.TypeOf(call.Args[0])
-		if t == nil {
-			continue
-		}
-		if _, sliceOk := t.Underlying().(*types.Slice); !sliceOk {
-			continue
-		}
+	ident, identOk := assign.Lhs[0].(*ast.Ident)
+	if !identOk || ident.Name != name {
+		return false
+	}
 
-		if len(assign.Lhs) == 1 {
-			lhsIdent, lOk := assign.Lhs[0].(*ast.Ident)
-			argIdent, rOk := call.Args[0].(*ast.Ident)
-			if lOk && rOk && lhsIdent.Name == argIdent.Name {
-				if preallocated[lhsIdent.Name] {
-					continue
-				}
-				diags = append(diags, rule.Diagnostic{
-					Rule:     "prealloc-slice",
-					Category: rule.CategoryPerf,
-					Severity: rule.SeverityWarning,
-					Pos:      ctx.FileSet.Position(assign.Pos()),
-					End:      ctx.FileSet.Position(assign.End()),
-					Message:  "consider preallocating '" + lhsIdent.Name + "'",
-				})
-			}
-		}
+	call, callOk := assign.Rhs[0].(*ast.CallExpr)
+	if !callOk {
+		return false
 	}
 
-	return diags
+	fnIdent, fnOk := call.Fun.(*ast.Ident)
+	return fnOk && fnIdent.Name == "make" && len(call.Args) >= 2
 }
 
 func init() {
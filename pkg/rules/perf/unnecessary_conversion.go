@@ -16,6 +16,7 @@ func (UnnecessaryConversion) Description() string {
 	return "Detects redundant type conversions (e.g., int(x) where x is already int)"
 }
 func (UnnecessaryConversion) NeedsTypeInfo() bool { return true }
+func (UnnecessaryConversion) Tags() []string      { return []string{"recommended"} }
 func (UnnecessaryConversion) NodeTypes() []ast.Node {
 	return []ast.Node{(*ast.CallExpr)(nil)}
 }
@@ -36,10 +37,17 @@ func (UnnecessaryConversion) Check(ctx *rule.Context, node ast.Node) []rule.Diag
 		return nil
 	}
 
-	argType := ctx.TypeInfo.TypeOf(call.Args[0])
-	if argType == nil {
+	// The recorded type of an untyped constant operand is the type it is
+	// converted to, but dropping the conversion would change it: float64(5)
+	// is not 5.
+	arg, ok := ctx.TypeInfo.Types[call.Args[0]]
+	if !ok || arg.Type == nil || arg.Value != nil {
 		return nil
 	}
+	if b, ok := arg.Type.(*types.Basic); ok && b.Info()&types.IsUntyped != 0 {
+		return nil
+	}
+	argType := arg.Type
 
 	convType := tv.Type
 	if types.Identical(argType, convType) {
@@ -50,12 +58,30 @@ func (UnnecessaryConversion) Check(ctx *rule.Context, node ast.Node) []rule.Diag
 			Pos:      ctx.FileSet.Position(call.Pos()),
 			End:      ctx.FileSet.Position(call.End()),
 			Message:  "unnecessary type conversion; expression is already of type " + argType.String(),
+			SuggestedFixes: []rule.SuggestedFix{{
+				Message: "remove the conversion",
+				Edits:   removeConversion(ctx, call),
+			}},
 		}}
 	}
 
 	return nil
 }
 
+// removeConversion drops T( and ) around the argument. Operands that
+// could bind differently without the call's parentheses keep them.
+func removeConversion(ctx *rule.Context, call *ast.CallExpr) []rule.TextEdit {
+	switch call.Args[0].(type) {
+	case *ast.Ident, *ast.BasicLit, *ast.CallExpr, *ast.SelectorExpr,
+		*ast.IndexExpr, *ast.ParenExpr, *ast.CompositeLit:
+		return []rule.TextEdit{
+			ctx.Edit(call.Pos(), call.Lparen+1, ""),
+			ctx.Edit(call.Rparen, call.End(), ""),
+		}
+	}
+	return []rule.TextEdit{ctx.Edit(call.Pos(), call.Lparen, "")}
+}
+
 func init() {
 	rule.Register(UnnecessaryConversion{})
 }
@@ -21,6 +21,7 @@ func (HardcodedSecret) Description() string {
 	return "Detects hardcoded secrets in string assignments (passwords, API keys, tokens)"
 }
 func (HardcodedSecret) NeedsTypeInfo() bool { return false }
+func (HardcodedSecret) Tags() []string      { return []string{"recommended"} }
 func (HardcodedSecret) NodeTypes() []ast.Node {
 	return []ast.Node{
 		(*ast.AssignStmt)(nil),
@@ -29,20 +30,30 @@ func (HardcodedSecret) NodeTypes() []ast.Node {
 	}
 }
 
+func (HardcodedSecret) OptionSchema() []rule.OptionSpec {
+	return []rule.OptionSpec{{
+		Name:        "patterns",
+		Type:        rule.OptionStringList,
+		Default:     defaultSecretPatterns,
+		Description: "case-insensitive substrings that mark a name as secret",
+	}}
+}
+
 func (HardcodedSecret) Check(ctx *rule.Context, node ast.Node) []rule.Diagnostic {
+	patterns := ctx.Options("hardcoded-secret").StringList("patterns", defaultSecretPatterns)
 	if a, aOk := node.(*ast.AssignStmt); aOk {
-		return checkAssign(ctx, a)
+		return checkAssign(ctx, a, patterns)
 	}
 	if v, vOk := node.(*ast.ValueSpec); vOk {
-		return checkValueSpec(ctx, v)
+		return checkValueSpec(ctx, v, patterns)
 	}
 	if kv, kvOk := node.(*ast.KeyValueExpr); kvOk {
-		return checkKeyValue(ctx, kv)
+		return checkKeyValue(ctx, kv, patterns)
 	}
 	return nil
 }
 
-func checkAssign(ctx *rule.Context, assign *ast.AssignStmt) []rule.Diagnostic {
+func checkAssign(ctx *rule.Context, assign *ast.AssignStmt, patterns []string) []rule.Diagnostic {
 	var diags []rule.Diagnostic
 	for i, lhs := range assign.Lhs {
 		ident, identOk := lhs.(*ast.Ident)
@@ -52,34 +63,34 @@ func checkAssign(ctx *rule.Context, assign *ast.AssignStmt) []rule.Diagnostic {
 		if i >= len(assign.Rhs) {
 			continue
 		}
-		if isSecretName(ident.Name) && isStringLiteral(assign.Rhs[i]) {
+		if isSecretName(ident.Name, patterns) && isStringLiteral(assign.Rhs[i]) {
 			diags = append(diags, makeDiag(ctx, ident, ident.Name))
 		}
 	}
 	return diags
 }
 
-func checkValueSpec(ctx *rule.Context, vs *ast.ValueSpec) []rule.Diagnostic {
+func checkValueSpec(ctx *rule.Context, vs *ast.ValueSpec, patterns []string) []rule.Diagnostic {
 	var diags []rule.Diagnostic
 	for i, name := range vs.Names {
-		if isSecretName(name.Name) && i < len(vs.Values) && isStringLiteral(vs.Values[i]) {
+		if isSecretName(name.Name, patterns) && i < len(vs.Values) && isStringLiteral(vs.Values[i]) {
 			diags = append(diags, makeDiag(ctx, name, name.Name))
 		}
 	}
 	return diags
 }
 
-func checkKeyValue(ctx *rule.Context, kv *ast.KeyValueExpr) []rule.Diagnostic {
+func checkKeyValue(ctx *rule.Context, kv *ast.KeyValueExpr, patterns []string) []rule.Diagnostic {
 	key, keyIsLit := kv.Key.(*ast.BasicLit)
 	if !keyIsLit {
 		ident, identOk := kv.Key.(*ast.Ident)
-		if identOk && isSecretName(ident.Name) && isStringLiteral(kv.Value) {
+		if identOk && isSecretName(ident.Name, patterns) && isStringLiteral(kv.Value) {
 			return []rule.Diagnostic{makeDiag(ctx, ident, ident.Name)}
 		}
 		return nil
 	}
 	keyStr := strings.Trim(key.Value, `"`)
-	if isSecretName(keyStr) && isStringLiteral(kv.Value) {
+	if isSecretName(keyStr, patterns) && isStringLiteral(kv.Value) {
 		return []rule.Diagnostic{{
 			Rule:     "hardcoded-secret",
 			Category: rule.CategorySecurity,
@@ -103,10 +114,10 @@ func makeDiag(ctx *rule.Context, ident *ast.Ident, name string) rule.Diagnostic
 	}
 }
 
-func isSecretName(name string) bool {
+func isSecretName(name string, patterns []string) bool {
 	lower := strings.ToLower(name)
-	for _, pat := range defaultSecretPatterns {
-		if strings.Contains(lower, pat) {
+	for _, pat := range patterns {
+		if strings.Contains(lower, strings.ToLower(pat)) {
 			return true
 		}
 	}
@@ -24,6 +24,7 @@ func (SQLInjection) Description() string {
 	return "Detects potential SQL injection via string concatenation in SQL query functions"
 }
 func (SQLInjection) NeedsTypeInfo() bool { return true }
+func (SQLInjection) Tags() []string      { return []string{"recommended"} }
 func (SQLInjection) NodeTypes() []ast.Node {
 	return []ast.Node{(*ast.CallExpr)(nil)}
 }
@@ -16,6 +16,7 @@ func (ImportOrder) Description() string {
 	return "Enforces import grouping: stdlib, then external, then internal"
 }
 func (ImportOrder) NeedsTypeInfo() bool { return false }
+func (ImportOrder) Tags() []string      { return []string{"opinionated"} }
 func (ImportOrder) NodeTypes() []ast.Node {
 	return nil
 }
@@ -51,10 +52,15 @@ func (ImportOrder) CheckFile(ctx *rule.Context) []rule.Diagnostic {
 			continue
 		}
 
+		var (
+			fix      rule.SuggestedFix
+			fixReady bool
+			fixOk    bool
+		)
 		lastGroup := -1
 		for _, imp := range imports {
 			if imp.group < lastGroup {
-				diags = append(diags, rule.Diagnostic{
+				d := rule.Diagnostic{
 					Rule:     "import-order",
 					Category: rule.CategoryStyle,
 					Severity: rule.SeverityInfo,
@@ -62,7 +68,17 @@ func (ImportOrder) CheckFile(ctx *rule.Context) []rule.Diagnostic {
 					End:      ctx.FileSet.Position(imp.spec.End()),
 					Message: "import '" + imp.path +
 						"' is out of order; expected grouping: stdlib, external, internal",
-				})
+				}
+				// Every diagnostic in the block carries the same block
+				// rewrite; identical edits are applied once.
+				if !fixReady {
+					fix, fixOk = regroupImports(ctx, gd, imports)
+					fixReady = true
+				}
+				if fixOk {
+					d.SuggestedFixes = []rule.SuggestedFix{fix}
+				}
+				diags = append(diags, d)
 			}
 			if imp.group > lastGroup {
 				lastGroup = imp.group
@@ -73,6 +89,49 @@ func (ImportOrder) CheckFile(ctx *rule.Context) []rule.Diagnostic {
 	return diags
 }
 
+// regroupImports rewrites a parenthesized import block as stdlib imports
+// followed by external ones, keeping the relative order within each
+// group. Blocks containing comments are left alone to avoid losing them.
+func regroupImports(ctx *rule.Context, gd *ast.GenDecl, imports []importInfo) (rule.SuggestedFix, bool) {
+	if !gd.Lparen.IsValid() || ctx.Src == nil {
+		return rule.SuggestedFix{}, false
+	}
+	for _, cg := range ctx.File.Comments {
+		if cg.Pos() > gd.Lparen && cg.End() < gd.Rparen {
+			return rule.SuggestedFix{}, false
+		}
+	}
+
+	groups := make([][]string, 3)
+	for _, imp := range imports {
+		start := ctx.FileSet.Position(imp.spec.Pos()).Offset
+		end := ctx.FileSet.Position(imp.spec.End()).Offset
+		if start < 0 || end > len(ctx.Src) {
+			return rule.SuggestedFix{}, false
+		}
+		groups[imp.group] = append(groups[imp.group], string(ctx.Src[start:end]))
+	}
+
+	var b strings.Builder
+	b.WriteString("\n")
+	for _, g := range groups {
+		if len(g) == 0 {
+			continue
+		}
+		if b.Len() > 1 {
+			b.WriteString("\n")
+		}
+		for _, spec := range g {
+			b.WriteString("\t" + spec + "\n")
+		}
+	}
+
+	return rule.SuggestedFix{
+		Message: "regroup imports",
+		Edits:   []rule.TextEdit{ctx.Edit(gd.Lparen+1, gd.Rparen, b.String())},
+	}, true
+}
+
 type importInfo struct {
 	path  string
 	group int // 0=stdlib, 1=external, 2=internal
@@ -2,7 +2,9 @@ package style
 
 import (
 	"bufio"
+	"bytes"
 	"go/ast"
+	"go/token"
 	"os"
 	"strconv"
 
@@ -19,38 +21,55 @@ func (LineLength) Severity() rule.Severity { return rule.SeverityWarning }
 func (LineLength) Description() string {
 	return "Reports lines exceeding a configurable maximum length"
 }
-func (LineLength) NeedsTypeInfo() bool  { return false }
+func (LineLength) NeedsTypeInfo() bool   { return false }
+func (LineLength) Tags() []string        { return []string{"opinionated"} }
 func (LineLength) NodeTypes() []ast.Node { return nil }
 
+func (LineLength) OptionSchema() []rule.OptionSpec {
+	return []rule.OptionSpec{{
+		Name:        "max",
+		Type:        rule.OptionInt,
+		Default:     defaultMaxLineLength,
+		Description: "maximum line length in bytes",
+	}}
+}
+
 func (LineLength) Check(_ *rule.Context, _ ast.Node) []rule.Diagnostic {
 	return nil
 }
 
 func (LineLength) CheckFile(ctx *rule.Context) []rule.Diagnostic {
-	f, err := os.Open(ctx.FilePath)
-	if err != nil {
+	src := ctx.Src
+	if src == nil {
+		data, err := os.ReadFile(ctx.FilePath)
+		if err != nil {
+			return nil
+		}
+		src = data
+	}
+	tf := ctx.FileSet.File(ctx.File.Pos())
+	if tf == nil {
 		return nil
 	}
-	defer f.Close()
 
-	maxLen := defaultMaxLineLength
+	maxLen := ctx.Options("line-length").Int("max", defaultMaxLineLength)
 
 	var diags []rule.Diagnostic
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(bytes.NewReader(src))
 	lineNum := 0
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
-		if len(line) > maxLen {
-			msg := ctx.FilePath + ":" + strconv.Itoa(lineNum) +
-				" line is " + strconv.Itoa(len(line)) +
-				" characters (max " + strconv.Itoa(maxLen) + ")"
+		if len(line) > maxLen && lineNum <= tf.LineCount() {
+			start := tf.LineStart(lineNum)
 			diags = append(diags, rule.Diagnostic{
 				Rule:     "line-length",
 				Category: rule.CategoryStyle,
 				Severity: rule.SeverityWarning,
-				Pos:      ctx.FileSet.Position(ctx.File.Pos()),
-				Message:  msg,
+				Pos:      ctx.FileSet.Position(start),
+				End:      ctx.FileSet.Position(start + token.Pos(len(line))),
+				Message: "line is " + strconv.Itoa(len(line)) +
+					" characters (max " + strconv.Itoa(maxLen) + ")",
 			})
 		}
 	}
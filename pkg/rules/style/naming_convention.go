@@ -2,6 +2,8 @@ package style
 
 import (
 	"go/ast"
+	"go/token"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -17,6 +19,7 @@ func (NamingConvention) Description() string {
 	return "Enforces Go naming conventions (MixedCaps, no underscores in exported names)"
 }
 func (NamingConvention) NeedsTypeInfo() bool { return false }
+func (NamingConvention) Tags() []string      { return []string{"recommended"} }
 func (NamingConvention) NodeTypes() []ast.Node {
 	return []ast.Node{
 		(*ast.FuncDecl)(nil),
@@ -86,6 +89,7 @@ func checkName(ctx *rule.Context, ident *ast.Ident) *rule.Diagnostic {
 				msg := "'" + mixed + "' in '" + name +
 					"' should be '" + acr +
 					"' (Go convention: " + lower + " -> " + acr + ")"
+				fixed := name[:idx] + acr + name[idx+len(mixed):]
 				d := rule.Diagnostic{
 					Rule:     "naming-convention",
 					Category: rule.CategoryStyle,
@@ -94,6 +98,12 @@ func checkName(ctx *rule.Context, ident *ast.Ident) *rule.Diagnostic {
 					End:      ctx.FileSet.Position(ident.End()),
 					Message:  msg,
 				}
+				if edits := renameInFile(ctx, ident, fixed); edits != nil {
+					d.SuggestedFixes = []rule.SuggestedFix{{
+						Message: "rename '" + name + "' to '" + fixed + "'",
+						Edits:   edits,
+					}}
+				}
 				return &d
 			}
 		}
@@ -102,6 +112,36 @@ func checkName(ctx *rule.Context, ident *ast.Ident) *rule.Diagnostic {
 	return nil
 }
 
+// renameInFile renames every reference to the object ident declares. It
+// returns nil, offering no fix, unless type information shows that the
+// object is only referenced in the current file and that no other package
+// can import it.
+func renameInFile(ctx *rule.Context, ident *ast.Ident, newName string) []rule.TextEdit {
+	if ctx.TypeInfo == nil {
+		return nil
+	}
+	obj := ctx.TypeInfo.Defs[ident]
+	if obj == nil || obj.Pkg() == nil || obj.Pkg().Name() != "main" {
+		return nil
+	}
+	inFile := func(pos token.Pos) bool {
+		return ctx.File.FileStart <= pos && pos <= ctx.File.FileEnd
+	}
+
+	edits := []rule.TextEdit{ctx.Edit(ident.Pos(), ident.End(), newName)}
+	for id, o := range ctx.TypeInfo.Uses {
+		if o != obj {
+			continue
+		}
+		if !inFile(id.Pos()) {
+			return nil
+		}
+		edits = append(edits, ctx.Edit(id.Pos(), id.End(), newName))
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+	return edits
+}
+
 var commonAcronyms = []string{
 	"API", "ASCII", "CPU", "CSS", "DNS", "EOF", "GUID",
 	"HTML", "HTTP", "HTTPS", "ID", "IP", "JSON", "LHS",
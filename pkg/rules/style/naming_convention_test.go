@@ -0,0 +1,123 @@
+package style
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+// checkNaming runs naming-convention over the first of files, type-checked
+// together as one package unless typed is false.
+func checkNaming(t *testing.T, typed bool, files ...string) []rule.Diagnostic {
+	t.Helper()
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for i, src := range files {
+		f, err := parser.ParseFile(fset, string(rune('a'+i))+".go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed = append(parsed, f)
+	}
+
+	ctx := &rule.Context{File: parsed[0], FileSet: fset}
+	if typed {
+		ctx.TypeInfo = &types.Info{
+			Defs: make(map[*ast.Ident]types.Object),
+			Uses: make(map[*ast.Ident]types.Object),
+		}
+		pkg, err := new(types.Config).Check("example.com/p", fset, parsed, ctx.TypeInfo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx.Pkg = pkg
+	}
+
+	var diags []rule.Diagnostic
+	ast.Inspect(parsed[0], func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncDecl, *ast.TypeSpec, *ast.ValueSpec:
+			diags = append(diags, NamingConvention{}.Check(ctx, n)...)
+		}
+		return true
+	})
+	return diags
+}
+
+func TestNamingConventionFix(t *testing.T) {
+	const mainFile = `package main
+
+type T struct{ UserId int }
+
+var UserId = 1
+
+func main() {
+	t := T{UserId: UserId}
+	_ = t.UserId + UserId
+}
+`
+	tests := []struct {
+		name  string
+		typed bool
+		files []string
+		want  []int // offsets of the renamed identifiers, or nil for no fix
+	}{
+		{
+			name:  "uses in the file",
+			typed: true,
+			files: []string{mainFile},
+			want:  []int{47, 89, 113}, // not the field of the same name
+		},
+		{
+			name:  "use in another file",
+			typed: true,
+			files: []string{mainFile, "package main\n\nvar _ = UserId\n"},
+		},
+		{
+			name:  "importable package",
+			typed: true,
+			files: []string{"package p\n\nvar UserId = 1\n"},
+		},
+		{
+			name:  "without type information",
+			files: []string{mainFile},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := checkNaming(t, tt.typed, tt.files...)
+			if len(diags) != 1 {
+				t.Fatalf("got %d diagnostics, want 1: %v", len(diags), diags)
+			}
+			d := diags[0]
+			if tt.want == nil {
+				if len(d.SuggestedFixes) != 0 {
+					t.Errorf("got fix %+v, want none", d.SuggestedFixes)
+				}
+				return
+			}
+			if len(d.SuggestedFixes) != 1 {
+				t.Fatalf("got %d fixes, want 1", len(d.SuggestedFixes))
+			}
+			var got []int
+			for _, e := range d.SuggestedFixes[0].Edits {
+				if e.NewText != "UserID" || e.End-e.Start != len("UserId") {
+					t.Errorf("edit %+v, want UserId replaced by UserID", e)
+				}
+				got = append(got, e.Start)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("edits at %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("edits at %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
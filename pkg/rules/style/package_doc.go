@@ -0,0 +1,55 @@
+package style
+
+import (
+	"go/ast"
+	"path/filepath"
+	"strings"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+type PackageDoc struct{}
+
+func (PackageDoc) Name() string            { return "package-doc" }
+func (PackageDoc) Category() rule.Category { return rule.CategoryStyle }
+func (PackageDoc) Severity() rule.Severity { return rule.SeverityInfo }
+func (PackageDoc) Description() string {
+	return "Reports package doc comments repeated in more than one file of a package"
+}
+func (PackageDoc) NeedsTypeInfo() bool   { return false }
+func (PackageDoc) Tags() []string        { return []string{"opinionated", "opt-in"} }
+func (PackageDoc) NodeTypes() []ast.Node { return nil }
+
+func (PackageDoc) Check(_ *rule.Context, _ ast.Node) []rule.Diagnostic {
+	return nil
+}
+
+func (PackageDoc) CheckPackage(ctx *rule.PackageContext) []rule.Diagnostic {
+	var (
+		diags []rule.Diagnostic
+		first string
+	)
+	for i, f := range ctx.Files {
+		// External test packages document themselves separately.
+		if f.Doc == nil || strings.HasSuffix(f.Name.Name, "_test") {
+			continue
+		}
+		if first == "" {
+			first = filepath.Base(ctx.FilePaths[i])
+			continue
+		}
+		diags = append(diags, rule.Diagnostic{
+			Rule:     "package-doc",
+			Category: rule.CategoryStyle,
+			Severity: rule.SeverityInfo,
+			Pos:      ctx.FileSet.Position(f.Doc.Pos()),
+			End:      ctx.FileSet.Position(f.Doc.End()),
+			Message:  "package " + f.Name.Name + " is already documented in " + first + "; keep the package doc in one file",
+		})
+	}
+	return diags
+}
+
+func init() {
+	rule.Register(PackageDoc{})
+}
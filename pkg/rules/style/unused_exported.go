@@ -0,0 +1,103 @@
+package style
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/nicholas/glint/pkg/rule"
+)
+
+type UnusedExported struct{}
+
+func (UnusedExported) Name() string            { return "unused-exported" }
+func (UnusedExported) Category() rule.Category { return rule.CategoryStyle }
+func (UnusedExported) Severity() rule.Severity { return rule.SeverityInfo }
+func (UnusedExported) Description() string {
+	return "Reports exported package-level identifiers that no other loaded package uses"
+}
+func (UnusedExported) NeedsTypeInfo() bool   { return true }
+func (UnusedExported) Tags() []string        { return []string{"opinionated", "opt-in"} }
+func (UnusedExported) NodeTypes() []ast.Node { return nil }
+
+func (UnusedExported) Check(_ *rule.Context, _ ast.Node) []rule.Diagnostic {
+	return nil
+}
+
+// usedObject identifies a package-level object across the variants of
+// its package: with tests loaded, importers see the plain package while
+// the test variant that is linted declares the same objects again.
+type usedObject struct {
+	pkgPath, name string
+}
+
+// CheckProgram only looks at packages imported by another loaded package:
+// the API of a package nothing imports is presumably meant for code
+// outside the analyzed program. Uses in test files don't count, so the
+// result is the same whether tests are loaded or not.
+func (UnusedExported) CheckProgram(ctx *rule.ProgramContext) []rule.Diagnostic {
+	imported := make(map[string]bool)
+	used := make(map[usedObject]bool)
+	for _, p := range ctx.Packages {
+		for _, path := range p.Imports {
+			imported[path] = true
+		}
+		if p.TypeInfo == nil {
+			continue
+		}
+		for id, obj := range p.TypeInfo.Uses {
+			if obj.Pkg() == nil || obj.Pkg().Path() == p.Path || obj.Parent() != obj.Pkg().Scope() {
+				continue
+			}
+			if strings.HasSuffix(ctx.FileSet.Position(id.Pos()).Filename, "_test.go") {
+				continue
+			}
+			used[usedObject{obj.Pkg().Path(), obj.Name()}] = true
+		}
+	}
+
+	var diags []rule.Diagnostic
+	for _, p := range ctx.Packages {
+		if p.Pkg == nil || p.Pkg.Name() == "main" || !imported[p.Path] {
+			continue
+		}
+		scope := p.Pkg.Scope()
+		for _, name := range scope.Names() {
+			obj := scope.Lookup(name)
+			if !obj.Exported() || used[usedObject{p.Pkg.Path(), name}] {
+				continue
+			}
+			pos := ctx.FileSet.Position(obj.Pos())
+			if strings.HasSuffix(pos.Filename, "_test.go") {
+				continue
+			}
+			diags = append(diags, rule.Diagnostic{
+				Rule:     "unused-exported",
+				Category: rule.CategoryStyle,
+				Severity: rule.SeverityInfo,
+				Pos:      pos,
+				End:      ctx.FileSet.Position(obj.Pos() + token.Pos(len(name))),
+				Message:  "exported " + objectKind(obj) + " " + name + " is not used outside package " + p.Pkg.Name(),
+			})
+		}
+	}
+	return diags
+}
+
+func objectKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Func:
+		return "function"
+	case *types.TypeName:
+		return "type"
+	case *types.Const:
+		return "constant"
+	default:
+		return "variable"
+	}
+}
+
+func init() {
+	rule.Register(UnusedExported{})
+}
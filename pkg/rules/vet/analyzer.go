@@ -0,0 +1,47 @@
+package vet
+
+import (
+	"go/ast"
+	"strings"
+
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Analyzer adapts a go/analysis analyzer to a glint rule named after it.
+// The engine runs it per package rather than through the walker, so
+// NodeTypes and Check are empty.
+type Analyzer struct {
+	analyzer *analysis.Analyzer
+	category rule.Category
+	severity rule.Severity
+}
+
+// Wrap returns a rule for a. Register it to make any third-party analyzer
+// available in .glint.yml:
+//
+//	rule.Register(vet.Wrap(myanalyzer.Analyzer, rule.CategoryBugs, rule.SeverityWarning))
+func Wrap(a *analysis.Analyzer, category rule.Category, severity rule.Severity) *Analyzer {
+	return &Analyzer{analyzer: a, category: category, severity: severity}
+}
+
+func (r *Analyzer) Name() string            { return r.analyzer.Name }
+func (r *Analyzer) Category() rule.Category { return r.category }
+func (r *Analyzer) Severity() rule.Severity { return r.severity }
+
+// Description returns the first line of the analyzer's documentation,
+// without the "name: " prefix most analyzers start with.
+func (r *Analyzer) Description() string {
+	doc, _, _ := strings.Cut(r.analyzer.Doc, "\n")
+	doc = strings.TrimPrefix(doc, r.analyzer.Name+": ")
+	return "go vet: " + doc
+}
+
+// Tags marks every analyzer with "vet", so that enable_tags can turn
+// them all on.
+func (r *Analyzer) Tags() []string { return []string{"vet"} }
+
+func (r *Analyzer) NeedsTypeInfo() bool                             { return true }
+func (r *Analyzer) NodeTypes() []ast.Node                           { return nil }
+func (r *Analyzer) Check(*rule.Context, ast.Node) []rule.Diagnostic { return nil }
+func (r *Analyzer) Analyzer() *analysis.Analyzer                    { return r.analyzer }
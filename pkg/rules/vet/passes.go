@@ -0,0 +1,84 @@
+package vet
+
+import (
+	"github.com/nicholas/glint/pkg/rule"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/appends"
+	"golang.org/x/tools/go/analysis/passes/assign"
+	"golang.org/x/tools/go/analysis/passes/atomic"
+	"golang.org/x/tools/go/analysis/passes/bools"
+	"golang.org/x/tools/go/analysis/passes/composite"
+	"golang.org/x/tools/go/analysis/passes/copylock"
+	"golang.org/x/tools/go/analysis/passes/deepequalerrors"
+	"golang.org/x/tools/go/analysis/passes/defers"
+	"golang.org/x/tools/go/analysis/passes/errorsas"
+	"golang.org/x/tools/go/analysis/passes/httpresponse"
+	"golang.org/x/tools/go/analysis/passes/ifaceassert"
+	"golang.org/x/tools/go/analysis/passes/lostcancel"
+	"golang.org/x/tools/go/analysis/passes/nilfunc"
+	"golang.org/x/tools/go/analysis/passes/nilness"
+	"golang.org/x/tools/go/analysis/passes/printf"
+	"golang.org/x/tools/go/analysis/passes/shift"
+	"golang.org/x/tools/go/analysis/passes/sigchanyzer"
+	"golang.org/x/tools/go/analysis/passes/slog"
+	"golang.org/x/tools/go/analysis/passes/sortslice"
+	"golang.org/x/tools/go/analysis/passes/stdmethods"
+	"golang.org/x/tools/go/analysis/passes/stringintconv"
+	"golang.org/x/tools/go/analysis/passes/structtag"
+	"golang.org/x/tools/go/analysis/passes/testinggoroutine"
+	"golang.org/x/tools/go/analysis/passes/tests"
+	"golang.org/x/tools/go/analysis/passes/timeformat"
+	"golang.org/x/tools/go/analysis/passes/unmarshal"
+	"golang.org/x/tools/go/analysis/passes/unreachable"
+	"golang.org/x/tools/go/analysis/passes/unsafeptr"
+	"golang.org/x/tools/go/analysis/passes/unusedresult"
+	"golang.org/x/tools/go/analysis/passes/unusedwrite"
+	"golang.org/x/tools/go/analysis/passes/waitgroup"
+)
+
+// passes are the analyzers from golang.org/x/tools registered as glint
+// rules. enable_all does not cover them: they are off unless enabled by
+// name, preset or their "vet" tag in .glint.yml.
+var passes = []struct {
+	analyzer *analysis.Analyzer
+	category rule.Category
+	severity rule.Severity
+}{
+	{appends.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{assign.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{atomic.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{bools.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{composite.Analyzer, rule.CategoryStyle, rule.SeverityInfo},
+	{copylock.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{deepequalerrors.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{defers.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{errorsas.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{httpresponse.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{ifaceassert.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{lostcancel.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{nilfunc.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{nilness.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{printf.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{shift.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{sigchanyzer.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{slog.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{sortslice.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{stdmethods.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{stringintconv.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{structtag.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{testinggoroutine.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{tests.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{timeformat.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{unmarshal.Analyzer, rule.CategoryBugs, rule.SeverityError},
+	{unreachable.Analyzer, rule.CategoryStyle, rule.SeverityWarning},
+	{unsafeptr.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{unusedresult.Analyzer, rule.CategoryBugs, rule.SeverityWarning},
+	{unusedwrite.Analyzer, rule.CategoryPerf, rule.SeverityInfo},
+	{waitgroup.Analyzer, rule.CategoryBugs, rule.SeverityError},
+}
+
+func init() {
+	for _, p := range passes {
+		rule.Register(Wrap(p.analyzer, p.category, p.severity))
+	}
+}
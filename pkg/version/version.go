@@ -0,0 +1,6 @@
+// Package version holds the glint release version.
+package version
+
+// Version is the glint version, overridable at build time with
+// -ldflags "-X github.com/nicholas/glint/pkg/version.Version=v1.2.3".
+var Version = "0.1.0"